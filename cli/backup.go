@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/nakurai/gourl/models"
+)
+
+type BackupCmd struct{}
+
+// return all the commands that will lead to this execution path
+func (c *BackupCmd) GetCmds() []string {
+	return []string{
+		"backup",
+	}
+}
+
+// return all the flags this cmd can handle
+func (c *BackupCmd) GetFlags() []ValidFlag {
+	return []ValidFlag{
+		{Key: "out", Labels: []string{"-o", "--out"}},
+		{Key: "secrets", Labels: []string{"--secrets"}},
+	}
+}
+
+// return all the flags this cmd can handle
+func (c *BackupCmd) GetHelp() string {
+	return `
+gourl backup --out <bundle.tar.gz> [--secrets keep|strip|encrypt]
+
+  Write all the saved queries, environments and example responses of the workspace in a bundle, to be restored with gourl restore on another computer. The bundle is a tar.gz archive of JSON files, described by its manifest.json.
+    --out,    -o  : The file of the bundle. Ex: --out gourl-backup.tar.gz
+    --secrets     : What to do with the secret variables (their name contains token, secret, password, api_key, etc): keep them (default), strip their value, or encrypt them with a passphrase. The passphrase is read from the GOURL_PASSPHRASE environment variable, or asked.`
+}
+
+func (c *BackupCmd) Execute(cmd string, actions []string, flags []Flag) (string, error) {
+	if len(actions) > 0 {
+		return fmt.Sprintf("Invalid action provided (%s). You must use the command as below:\n%s\n", actions[0], c.GetHelp()), nil
+	}
+
+	outPath := ""
+	secrets := models.SecretsKeep
+	for _, flag := range flags {
+		switch flag.Key {
+		case "out":
+			outPath = flag.Value
+		case "secrets":
+			secrets = flag.Value
+		default:
+			return "", fmt.Errorf("the %s flag is unknown. Use `gourl backup` to list all the options", flag.Key)
+		}
+	}
+	if outPath == "" {
+		return "", fmt.Errorf("the --out flag is mandatory. Use `gourl backup` to list all the options")
+	}
+
+	passphrase := ""
+	if secrets == models.SecretsEncrypt {
+		var err error
+		passphrase, err = readPassphrase(true)
+		if err != nil {
+			return "", err
+		}
+	}
+	bundle, err := models.NewBundle(secrets, passphrase)
+	if err != nil {
+		return "", err
+	}
+
+	// the bundle may contain secrets, only the user can read it
+	file, err := os.OpenFile(outPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return "", fmt.Errorf("while creating %s: %v", outPath, err)
+	}
+	defer file.Close()
+	err = bundle.Write(file)
+	if err != nil {
+		return "", err
+	}
+
+	counts := bundle.Manifest.Counts
+	res := fmt.Sprintf("%d queries, %d environments and %d examples saved in %s", counts["queries"], counts["environments"], counts["examples"], outPath)
+	if counts["secrets"] > 0 {
+		res += fmt.Sprintf("\n%d secret variables: %s", counts["secrets"], map[string]string{
+			models.SecretsKeep:    "kept as is, keep the bundle safe",
+			models.SecretsStrip:   "their value is stripped",
+			models.SecretsEncrypt: "encrypted with the passphrase",
+		}[secrets])
+	}
+	return res, nil
+}
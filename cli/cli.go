@@ -1,6 +1,9 @@
 package cli
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 // all possible commands must implement this interface
 type CmdInterface interface {
@@ -101,3 +104,32 @@ func (c *Cli) ParseArgs(args []string) ([]string, []Flag, error) {
 	}
 	return actions, flags, nil
 }
+
+// remove the flag and its value from the arguments, for the flags handled before
+// any command is executed, ex: --workspace
+func ExtractFlag(args []string, label string) (string, []string, error) {
+	value := ""
+	rest := []string{}
+	for argIndex := 0; argIndex < len(args); argIndex++ {
+		if args[argIndex] != label {
+			rest = append(rest, args[argIndex])
+			continue
+		}
+		if argIndex == len(args)-1 {
+			return "", nil, fmt.Errorf("it looks like the flag %s has no value. All flags must be provided a value", label)
+		}
+		argIndex++
+		value = args[argIndex]
+	}
+	return value, rest, nil
+}
+
+// split the value of a key=value flag, ex: --data key=value.
+// Only the first = is used as a separator, so the value itself can contain = signs
+func splitKeyValue(flagKey string, value string) (string, string, error) {
+	key, val, ok := strings.Cut(value, "=")
+	if !ok || key == "" {
+		return "", "", fmt.Errorf("wrong formatting %s. The --%s flag must be --%s key=value", value, flagKey, flagKey)
+	}
+	return key, val, nil
+}
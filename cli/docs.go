@@ -0,0 +1,277 @@
+package cli
+
+import (
+	"fmt"
+	"html"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/nakurai/gourl/models"
+)
+
+type DocsCmd struct{}
+
+// return all the commands that will lead to this execution path
+func (c *DocsCmd) GetCmds() []string {
+	return []string{
+		"docs",
+	}
+}
+
+// return all the flags this cmd can handle
+func (c *DocsCmd) GetFlags() []ValidFlag {
+	return []ValidFlag{
+		{Key: "format", Labels: []string{"-f", "--format"}},
+		{Key: "out", Labels: []string{"-o", "--out"}},
+		{Key: "prefix", Labels: []string{"-p", "--prefix"}},
+		{Key: "tag", Labels: []string{"-t", "--tag"}},
+	}
+}
+
+// return all the flags this cmd can handle
+func (c *DocsCmd) GetHelp() string {
+	return `
+gourl docs [--format markdown|html] [--out <file>] [--prefix <folder>] [--tag <tag>]
+
+  Render the documentation of your saved queries: their description, tags, method, url, headers, cookies, data and most recent example response. Variables are kept as placeholders.
+    --format, -f  : markdown (default) or html.
+    --out,    -o  : Write the documentation in this file instead of displaying it. Ex: --out api.md
+    --prefix, -p  : Only document the queries saved under this folder. Ex: --prefix demo/api
+    --tag,    -t  : Only document the queries having this tag. You can use this flag several times.`
+}
+
+func (c *DocsCmd) Execute(cmd string, actions []string, flags []Flag) (string, error) {
+	format := "markdown"
+	outPath := ""
+	prefix := ""
+	tags := []string{}
+	for _, flag := range flags {
+		switch flag.Key {
+		case "format":
+			format = flag.Value
+		case "out":
+			outPath = flag.Value
+		case "prefix":
+			prefix = strings.Trim(flag.Value, "/")
+		case "tag":
+			tags = append(tags, flag.Value)
+		default:
+			return "", fmt.Errorf("the %s flag is unknown. Use `gourl help` to list all the options", flag.Key)
+		}
+	}
+
+	var render func(folders []docFolder, examples map[uint]*models.Example) string
+	switch format {
+	case "markdown", "md":
+		render = renderMarkdownDocs
+	case "html":
+		render = renderHtmlDocs
+	default:
+		return "", fmt.Errorf("unknown format %s. The --format flag must be markdown or html", format)
+	}
+
+	queries, err := models.GetAllQueries()
+	if err != nil {
+		return "", err
+	}
+	selected := []models.Query{}
+	examples := map[uint]*models.Example{}
+	for _, query := range queries {
+		if prefix != "" && !strings.HasPrefix(query.Name, prefix+"/") {
+			continue
+		}
+		hasAllTags := true
+		for _, tag := range tags {
+			hasAllTags = hasAllTags && query.HasTag(tag)
+		}
+		if !hasAllTags {
+			continue
+		}
+		selected = append(selected, query)
+		examples[query.ID], err = models.GetLatestExample(query.ID)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	docs := render(groupByFolder(selected), examples)
+	if outPath == "" {
+		return docs, nil
+	}
+	err = os.WriteFile(outPath, []byte(docs), 0644)
+	if err != nil {
+		return "", fmt.Errorf("while writing the documentation in %s: %v", outPath, err)
+	}
+	return fmt.Sprintf("%d queries documented in %s", len(selected), outPath), nil
+}
+
+// the queries saved in the same folder, ex: demo/test
+type docFolder struct {
+	Name    string
+	Queries []models.Query
+}
+
+// group the queries by folder, sorted by name
+func groupByFolder(queries []models.Query) []docFolder {
+	byFolder := map[string][]models.Query{}
+	for _, query := range queries {
+		folder := path.Dir(query.Name)
+		if folder == "." {
+			folder = "/"
+		}
+		byFolder[folder] = append(byFolder[folder], query)
+	}
+	folders := []docFolder{}
+	for name, folderQueries := range byFolder {
+		sort.Slice(folderQueries, func(i, j int) bool {
+			return folderQueries[i].Name < folderQueries[j].Name
+		})
+		folders = append(folders, docFolder{Name: name, Queries: folderQueries})
+	}
+	sort.Slice(folders, func(i, j int) bool {
+		return folders[i].Name < folders[j].Name
+	})
+	return folders
+}
+
+// explain how the data of the query is sent
+func dataLocation(query models.Query) string {
+	if !query.HasBody() || query.Body != "" {
+		return "query string"
+	}
+	if query.IsMultipart {
+		return "multipart form body"
+	}
+	if query.IsJson {
+		return "JSON body"
+	}
+	return "form encoded body"
+}
+
+// return all the variables used by the query
+func queryVariables(query models.Query) []string {
+	values := []string{query.Url, query.Body}
+	for _, m := range []models.JSONMap{query.Header, query.Cookie, query.Data} {
+		for _, key := range sortedKeys(m) {
+			values = append(values, m[key])
+		}
+	}
+	names := []string{}
+	seen := map[string]bool{}
+	for _, value := range values {
+		for _, name := range models.VariableNames(value) {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+func renderMarkdownDocs(folders []docFolder, examples map[uint]*models.Example) string {
+	var doc strings.Builder
+	doc.WriteString("# Gourl collection\n")
+	for _, folder := range folders {
+		fmt.Fprintf(&doc, "\n## %s\n", folder.Name)
+		for _, query := range folder.Queries {
+			fmt.Fprintf(&doc, "\n### %s\n\n", path.Base(query.Name))
+			if query.Description != "" {
+				fmt.Fprintf(&doc, "%s\n\n", query.Description)
+			}
+			fmt.Fprintf(&doc, "```\n%s %s\n```\n\n", query.Method, query.Url)
+			fmt.Fprintf(&doc, "Saved as `%s`", query.Name)
+			if tags := query.TagList(); len(tags) > 0 {
+				fmt.Fprintf(&doc, " - tags: %s", strings.Join(tags, ", "))
+			}
+			doc.WriteString("\n")
+			if variables := queryVariables(query); len(variables) > 0 {
+				fmt.Fprintf(&doc, "\nVariables: `%s`\n", strings.Join(variables, "`, `"))
+			}
+			markdownTable(&doc, "Headers", query.Header)
+			markdownTable(&doc, "Cookies", query.Cookie)
+			markdownTable(&doc, fmt.Sprintf("Data (%s)", dataLocation(query)), query.Data)
+			if query.Body != "" {
+				fmt.Fprintf(&doc, "\n**Body**\n\n```\n%s\n```\n", query.Body)
+			}
+			if example := examples[query.ID]; example != nil {
+				fmt.Fprintf(&doc, "\n**Example response** (%s)\n\n```\n%s\n```\n", example.Status, example.PrettyBody())
+			}
+		}
+	}
+	return doc.String()
+}
+
+func markdownTable(doc *strings.Builder, title string, m models.JSONMap) {
+	if len(m) == 0 {
+		return
+	}
+	escape := func(s string) string {
+		return strings.ReplaceAll(s, "|", "\\|")
+	}
+	fmt.Fprintf(doc, "\n**%s**\n\n| Name | Value |\n| --- | --- |\n", title)
+	for _, key := range sortedKeys(m) {
+		fmt.Fprintf(doc, "| %s | %s |\n", escape(key), escape(m[key]))
+	}
+}
+
+func renderHtmlDocs(folders []docFolder, examples map[uint]*models.Example) string {
+	var doc strings.Builder
+	doc.WriteString(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Gourl collection</title>
+<style>
+body { font-family: sans-serif; max-width: 60em; margin: auto; }
+pre { background: #f4f4f4; padding: 0.5em; }
+table { border-collapse: collapse; }
+td, th { border: 1px solid #ccc; padding: 0.2em 0.5em; text-align: left; }
+</style>
+</head>
+<body>
+<h1>Gourl collection</h1>
+`)
+	for _, folder := range folders {
+		fmt.Fprintf(&doc, "<h2>%s</h2>\n", html.EscapeString(folder.Name))
+		for _, query := range folder.Queries {
+			fmt.Fprintf(&doc, "<h3>%s</h3>\n", html.EscapeString(path.Base(query.Name)))
+			if query.Description != "" {
+				fmt.Fprintf(&doc, "<p>%s</p>\n", html.EscapeString(query.Description))
+			}
+			fmt.Fprintf(&doc, "<pre>%s %s</pre>\n", html.EscapeString(query.Method), html.EscapeString(query.Url))
+			fmt.Fprintf(&doc, "<p>Saved as <code>%s</code>", html.EscapeString(query.Name))
+			if tags := query.TagList(); len(tags) > 0 {
+				fmt.Fprintf(&doc, " - tags: %s", html.EscapeString(strings.Join(tags, ", ")))
+			}
+			doc.WriteString("</p>\n")
+			if variables := queryVariables(query); len(variables) > 0 {
+				fmt.Fprintf(&doc, "<p>Variables: <code>%s</code></p>\n", html.EscapeString(strings.Join(variables, ", ")))
+			}
+			htmlTable(&doc, "Headers", query.Header)
+			htmlTable(&doc, "Cookies", query.Cookie)
+			htmlTable(&doc, fmt.Sprintf("Data (%s)", dataLocation(query)), query.Data)
+			if query.Body != "" {
+				fmt.Fprintf(&doc, "<h4>Body</h4>\n<pre>%s</pre>\n", html.EscapeString(query.Body))
+			}
+			if example := examples[query.ID]; example != nil {
+				fmt.Fprintf(&doc, "<h4>Example response (%s)</h4>\n<pre>%s</pre>\n", html.EscapeString(example.Status), html.EscapeString(example.PrettyBody()))
+			}
+		}
+	}
+	doc.WriteString("</body>\n</html>\n")
+	return doc.String()
+}
+
+func htmlTable(doc *strings.Builder, title string, m models.JSONMap) {
+	if len(m) == 0 {
+		return
+	}
+	fmt.Fprintf(doc, "<h4>%s</h4>\n<table>\n<tr><th>Name</th><th>Value</th></tr>\n", html.EscapeString(title))
+	for _, key := range sortedKeys(m) {
+		fmt.Fprintf(doc, "<tr><td>%s</td><td>%s</td></tr>\n", html.EscapeString(key), html.EscapeString(m[key]))
+	}
+	doc.WriteString("</table>\n")
+}
@@ -22,6 +22,7 @@ func (c *EnvCmd) GetFlags() []ValidFlag {
 		{Key: "name", Labels: []string{"-n", "--name"}},
 		{Key: "description", Labels: []string{"-desc", "--description"}},
 		{Key: "copy", Labels: []string{"-cp", "--copy"}},
+		{Key: "parent", Labels: []string{"--parent"}},
 	}
 }
 
@@ -32,13 +33,20 @@ gourl env list
 
   List all the available environments
 
-gourl env add --name <name> [--copy <name>] [--description <your description>]
+gourl env add --name <name> [--copy <name>] [--parent <name>] [--description <your description>]
 
   Create a new environment. If the copy flag is used, all variables (and their values) of this other environment will be copied over.
 	--name,        -n:    An arbitrary string to name your environment.
 	--copy,        -cp:   An existing environment name.
+	--parent            : An existing environment whose variables are inherited, unless the new environment defines them too.
 	--description, -desc: A description of the environment.
 
+gourl env parent --name <name> --parent <name|none>
+
+  Change the environment whose variables are inherited. The variables are looked up in the environment, then its parent, the parent of its parent, etc, then in the global variables (see gourl var add --global true).
+	--name,        -n:    An existing environment name.
+	--parent            : An existing environment name, or none to stop inheriting variables.
+
 gourl env remove --name <name>
 
   Delete an environment. It will also delete all the variables linked to this environment, and their values. If the deleted environment is the currently loaded one, then the default environment will be loaded automatically.
@@ -72,17 +80,27 @@ func (c *EnvCmd) Execute(cmd string, actions []string, flags []Flag) (string, er
 		}
 		allEnvs := ""
 		for _, env := range envs {
+			// the global variables are listed by gourl var list
+			if env.Name == models.GlobalsEnvName {
+				continue
+			}
 			allEnvs += fmt.Sprintf("%v\n", env)
 		}
+		if !models.CurrentEnv.Current {
+			allEnvs += fmt.Sprintf("\n%s is used instead of the current environment, because of GOURL_ENV\n", models.CurrentEnv.Name)
+		}
 		return allEnvs, nil
 	case "add":
 		newName := ""
 		newDesc := ""
 		copyFrom := ""
+		parent := ""
 		for _, flag := range flags {
 			switch flag.Key {
 			case "name":
 				newName = flag.Value
+			case "parent":
+				parent = flag.Value
 			case "copy":
 				copyFrom = flag.Value
 			case "description":
@@ -100,9 +118,15 @@ func (c *EnvCmd) Execute(cmd string, actions []string, flags []Flag) (string, er
 		if err != nil {
 			return "", err
 		}
-		if existingEnv != nil {
+		if existingEnv != nil || newName == models.GlobalsEnvName {
 			return "", fmt.Errorf("an environment named %s already exists", newName)
 		}
+		if parent != "" {
+			err := checkParentEnv(newName, parent)
+			if err != nil {
+				return "", err
+			}
+		}
 
 		envVars := map[string]string{}
 		if copyFrom != "" {
@@ -120,6 +144,7 @@ func (c *EnvCmd) Execute(cmd string, actions []string, flags []Flag) (string, er
 			Name:      newName,
 			Variables: envVars,
 			Description: newDesc,
+			Parent:    parent,
 			Current:   false,
 		}
 		err = models.CreateEnv(&newEnv)
@@ -153,6 +178,14 @@ func (c *EnvCmd) Execute(cmd string, actions []string, flags []Flag) (string, er
 		if existingEnv == nil {
 			return "", fmt.Errorf("no environment named %s exists", nameToDelete)
 		}
+		children := []models.Environment{}
+		res := db.Db.Where("parent = ?", nameToDelete).Find(&children)
+		if res.Error != nil {
+			return "", fmt.Errorf("while fetching the environments inheriting from %s: %v", nameToDelete, res.Error)
+		}
+		if len(children) > 0 {
+			return "", fmt.Errorf("the environment %s cannot be deleted, %s inherits its variables. Use `gourl env parent` to change it", nameToDelete, children[0].Name)
+		}
 
 		db.Db.Delete(existingEnv)
 
@@ -162,12 +195,12 @@ func (c *EnvCmd) Execute(cmd string, actions []string, flags []Flag) (string, er
 			models.LoadEnv("default")
 		}
 
-		res := fmt.Sprintf("env %s deleted.", nameToDelete)
+		output := fmt.Sprintf("env %s deleted.", nameToDelete)
 		if wasExistingEnv{
-			res += "Default environment loaded."
+			output += "Default environment loaded."
 		}
 
-		return res, nil
+		return output, nil
 	case "load":
 		nameToLoad := ""
 		for _, flag := range flags {
@@ -187,7 +220,7 @@ func (c *EnvCmd) Execute(cmd string, actions []string, flags []Flag) (string, er
 		if err != nil {
 			return "", err
 		}
-		if existingEnv == nil {
+		if existingEnv == nil || nameToLoad == models.GlobalsEnvName {
 			return "", fmt.Errorf("no environment named %s exists", nameToLoad)
 		}
 
@@ -195,8 +228,78 @@ func (c *EnvCmd) Execute(cmd string, actions []string, flags []Flag) (string, er
 
 		return fmt.Sprintf("%s loaded", nameToLoad), nil
 
+	case "parent":
+		name := ""
+		parent := ""
+		for _, flag := range flags {
+			switch flag.Key {
+			case "name":
+				name = flag.Value
+			case "parent":
+				parent = flag.Value
+			default:
+				return "", fmt.Errorf("the %s flag is unknown. Use `gourl env` to list all the options", flag.Key)
+			}
+		}
+		if name == "" || parent == "" {
+			return "", fmt.Errorf("the --name and --parent flags are mandatory. Use `gourl env` to list all the options")
+		}
+
+		env, err := models.GetEnv(name)
+		if err != nil {
+			return "", err
+		}
+		if env == nil || name == models.GlobalsEnvName {
+			return "", fmt.Errorf("no environment named %s exists", name)
+		}
+		if parent == "none" {
+			parent = ""
+		} else {
+			err := checkParentEnv(name, parent)
+			if err != nil {
+				return "", err
+			}
+		}
+		env.Parent = parent
+		res := db.Db.Save(env)
+		if res.Error != nil {
+			return "", fmt.Errorf("while saving the environment %s: %v", name, res.Error)
+		}
+		if models.CurrentEnv.Name == name {
+			models.CurrentEnv.Parent = parent
+		}
+		if parent == "" {
+			return fmt.Sprintf("%s does not inherit variables anymore", name), nil
+		}
+		return fmt.Sprintf("%s inherits the variables of %s", name, parent), nil
+
 	default:
 		return fmt.Sprintf("Invalid action provided (%s). You must provide one of the actions below:\n%s\n", action, c.GetHelp()), nil
 	}
 
 }
+
+// check the environment can inherit from the parent: it must exist and not
+// inherit from the environment, directly or not
+func checkParentEnv(name string, parent string) error {
+	if name == parent {
+		return fmt.Errorf("an environment cannot inherit from itself")
+	}
+	parentEnv, err := models.GetEnv(parent)
+	if err != nil {
+		return err
+	}
+	if parentEnv == nil || parent == models.GlobalsEnvName {
+		return fmt.Errorf("no environment named %s exists, impossible to inherit from it", parent)
+	}
+	chain, err := parentEnv.Chain()
+	if err != nil {
+		return err
+	}
+	for _, env := range chain {
+		if env.Name == name {
+			return fmt.Errorf("%s cannot inherit from %s, since %s already inherits from %s", name, parent, parent, name)
+		}
+	}
+	return nil
+}
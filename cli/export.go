@@ -0,0 +1,243 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/nakurai/gourl/convert"
+	"github.com/nakurai/gourl/models"
+)
+
+type ExportCmd struct{}
+
+// return all the commands that will lead to this execution path
+func (c *ExportCmd) GetCmds() []string {
+	return []string{
+		"export",
+	}
+}
+
+// return all the flags this cmd can handle
+func (c *ExportCmd) GetFlags() []ValidFlag {
+	return []ValidFlag{
+		{Key: "name", Labels: []string{"-n", "--name"}},
+		{Key: "as", Labels: []string{"--as"}},
+		{Key: "expand", Labels: []string{"--expand"}},
+		{Key: "out", Labels: []string{"-o", "--out"}},
+		{Key: "prefix", Labels: []string{"-p", "--prefix"}},
+		{Key: "run", Labels: []string{"-r", "--run"}},
+	}
+}
+
+// return all the flags this cmd can handle
+func (c *ExportCmd) GetHelp() string {
+	return `
+gourl export --name <name> --as curl|httpie|go|python-requests|js-fetch [--expand true] [--out <file>]
+
+  Render a saved query as a command or a code snippet, ready to be pasted in a bug report or in your code.
+    --name,   -n  : The full name of the saved query.
+    --as          : The language of the snippet: ` + strings.Join(convert.SnippetLanguages, ", ") + `
+    --expand      : If true, the variables are replaced by their value in the current environment. Otherwise they are read from the environment variables of the same name when the snippet is executed. It is required by the variables reading a file, the functions and the composed names.
+    --out,    -o  : Write the snippet in this file instead of displaying it.
+
+gourl export insomnia [--prefix <folder>] [--out <file>]
+
+  Write the saved queries and the environments as an Insomnia export (v4 format), ready to be imported in Insomnia. The folders become request groups.
+    --prefix, -p  : Only export the queries saved under this folder. Ex: --prefix demo/api
+    --out,    -o  : Write the export in this file instead of displaying it. Ex: --out insomnia.json
+
+gourl export har [--name <name>] [--prefix <folder>] [--run true] [--out <file>]
+
+  Write saved queries as a HAR 1.2 document, with their variables expanded. The responses are the most recent examples of the queries, or the live responses and their timings with --run.
+    --name,   -n  : Only export this saved query.
+    --prefix, -p  : Only export the queries saved under this folder. Ex: --prefix demo/api
+    --run,    -r  : If true, the queries are sent and their responses and timings are recorded.
+    --out,    -o  : Write the HAR document in this file instead of displaying it. Ex: --out session.har
+
+gourl export http [--prefix <folder>] [--out <file>]
+
+  Write the saved queries as a .http file, to be executed by gourl run, the VS Code REST Client or the JetBrains http client. The variables are kept as {{var}} placeholders.
+    --prefix, -p  : Only export the queries saved under this folder. Ex: --prefix demo/api
+    --out,    -o  : Write the queries in this file instead of displaying them. Ex: --out api.http`
+}
+
+func (c *ExportCmd) Execute(cmd string, actions []string, flags []Flag) (string, error) {
+	name := ""
+	language := ""
+	expand := false
+	outPath := ""
+	prefix := ""
+	run := false
+	for _, flag := range flags {
+		switch flag.Key {
+		case "name":
+			name = flag.Value
+		case "as":
+			language = flag.Value
+		case "expand":
+			expand = flag.Value == "true"
+		case "out":
+			outPath = flag.Value
+		case "prefix":
+			prefix = strings.Trim(flag.Value, "/")
+		case "run":
+			run = flag.Value == "true"
+		default:
+			return "", fmt.Errorf("the %s flag is unknown. Use `gourl export` to list all the options", flag.Key)
+		}
+	}
+
+	if len(actions) > 0 {
+		switch actions[0] {
+		case "insomnia":
+			queries, err := queriesUnder(prefix)
+			if err != nil {
+				return "", err
+			}
+			envs, err := models.GetAllEnvs()
+			if err != nil {
+				return "", err
+			}
+			content, warnings, err := convert.ExportInsomnia(queries, envs)
+			if err != nil {
+				return "", err
+			}
+			return writeOutputWithWarnings(outPath, content, warnings)
+		case "http":
+			queries, err := queriesUnder(prefix)
+			if err != nil {
+				return "", err
+			}
+			content, warnings, err := convert.ExportHttpFile(queries)
+			if err != nil {
+				return "", err
+			}
+			return writeOutputWithWarnings(outPath, content, warnings)
+		case "har":
+			queries := []models.Query{}
+			if name != "" {
+				query, err := models.GetQuery(name)
+				if err != nil {
+					return "", err
+				}
+				if query == nil {
+					return "", fmt.Errorf("no query named %s exists", name)
+				}
+				queries = append(queries, *query)
+			} else {
+				var err error
+				queries, err = queriesUnder(prefix)
+				if err != nil {
+					return "", err
+				}
+			}
+			exchanges := []convert.HarExchange{}
+			for _, query := range queries {
+				exchange, err := harExchange(query, run)
+				if err != nil {
+					return "", err
+				}
+				exchanges = append(exchanges, *exchange)
+			}
+			content, err := convert.ExportHar(exchanges)
+			if err != nil {
+				return "", err
+			}
+			// HAR files are shared to debug, they must not leak the secret variables
+			return writeOutput(outPath, models.MaskSecrets(content))
+		default:
+			return fmt.Sprintf("Invalid action provided (%s). You must use the command as below:\n%s\n", actions[0], c.GetHelp()), nil
+		}
+	}
+
+	if name == "" || language == "" {
+		return "", fmt.Errorf("the --name and --as flags are mandatory. Use `gourl export` to list all the options")
+	}
+
+	query, err := models.GetQuery(name)
+	if err != nil {
+		return "", err
+	}
+	if query == nil {
+		return "", fmt.Errorf("no query named %s exists", name)
+	}
+	snippet, err := convert.ExportSnippet(*query, language, expand)
+	if err != nil {
+		return "", err
+	}
+	return writeOutput(outPath, snippet)
+}
+
+// write the content in the file, or return it to be displayed if no file is provided
+func writeOutput(outPath string, content string) (string, error) {
+	if outPath == "" {
+		return content, nil
+	}
+	err := os.WriteFile(outPath, []byte(content), 0644)
+	if err != nil {
+		return "", fmt.Errorf("while writing %s: %v", outPath, err)
+	}
+	return fmt.Sprintf("written in %s", outPath), nil
+}
+
+// write the content like writeOutput, along with the warnings. When the content is displayed,
+// the warnings are written on the standard error, so the content can be redirected as is
+func writeOutputWithWarnings(outPath string, content string, warnings []string) (string, error) {
+	if outPath == "" {
+		fmt.Fprint(os.Stderr, formatWarnings(warnings))
+		return content, nil
+	}
+	res, err := writeOutput(outPath, content)
+	if err != nil {
+		return "", err
+	}
+	return formatWarnings(warnings) + res, nil
+}
+
+// return the saved queries under the folder, or all of them if no folder is provided
+func queriesUnder(prefix string) ([]models.Query, error) {
+	queries, err := models.GetAllQueries()
+	if err != nil {
+		return nil, err
+	}
+	if prefix == "" {
+		return queries, nil
+	}
+	selected := []models.Query{}
+	for _, query := range queries {
+		if strings.HasPrefix(query.Name, prefix+"/") {
+			selected = append(selected, query)
+		}
+	}
+	return selected, nil
+}
+
+// build the request of the query and its response: the live one if run is true,
+// otherwise its most recent example, if any
+func harExchange(query models.Query, run bool) (*convert.HarExchange, error) {
+	req, err := query.NewRequest()
+	if err != nil {
+		return nil, fmt.Errorf("while building the request of %s: %v", query.Name, err)
+	}
+	body, err := convert.ReadRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	exchange := convert.HarExchange{Request: req, RequestBody: body}
+	if run {
+		exchange.Response, err = models.DoRequest(req)
+		if err != nil {
+			return nil, fmt.Errorf("while sending %s: %v", query.Name, err)
+		}
+		return &exchange, nil
+	}
+	example, err := models.GetLatestExample(query.ID)
+	if err != nil {
+		return nil, err
+	}
+	if example != nil {
+		exchange.Response = example.ToResponse()
+	}
+	return &exchange, nil
+}
@@ -0,0 +1,391 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/nakurai/gourl/convert"
+	"github.com/nakurai/gourl/db"
+	"github.com/nakurai/gourl/models"
+)
+
+type ImportCmd struct{}
+
+// return all the commands that will lead to this execution path
+func (c *ImportCmd) GetCmds() []string {
+	return []string{
+		"import",
+	}
+}
+
+// return all the flags this cmd can handle
+func (c *ImportCmd) GetFlags() []ValidFlag {
+	return append([]ValidFlag{
+		{Key: "file", Labels: []string{"--file"}},
+		{Key: "save", Labels: []string{"-s", "--save"}},
+		{Key: "prefix", Labels: []string{"-p", "--prefix"}},
+		{Key: "name", Labels: []string{"-n", "--name"}},
+		{Key: "url", Labels: []string{"-u", "--url"}},
+		{Key: "method", Labels: []string{"-m", "--method"}},
+	}, sendFlags()...)
+}
+
+// return all the flags this cmd can handle
+func (c *ImportCmd) GetHelp() string {
+	return `
+gourl import curl [<curl command>] [--file <path>] [--save <name>] [--verbose true]
+
+  Convert a curl command into a query. The command is read from the arguments (surrounded by quotes), from a file or from stdin. The options which cannot be converted are reported. Without --save, the query is executed.
+    --file        : Read the curl command from this file. Ex: --file ./request.sh
+    --save,   -s  : Save the query under this name instead of executing it. Ex: --save demo/users/update
+` + sendFlagsHelp + `
+
+gourl import postman <collection.json> [--prefix <folder>]
+
+  Save all the requests of a Postman collection (v2.1). The folders of the collection become the folders of the queries and the {{var}} variables become %{var}% variables. The queries already existing are skipped, and what cannot be converted (scripts, auth types, etc) is reported.
+    --prefix, -p  : Save the queries under this folder instead of the name of the collection. Ex: --prefix demo/api
+
+gourl import postman-env <environment.json> [--name <env name>]
+
+  Create an environment with the variables of a Postman environment.
+    --name,   -n  : Name of the environment to create instead of the name of the Postman environment. Ex: --name staging
+
+gourl import insomnia <export.json> [--prefix <folder>]
+
+  Save all the requests and environments of an Insomnia export (v4 format). The request groups become the folders of the queries, and the variables of the base environment are merged into each environment. The queries and environments already existing are skipped.
+    --prefix, -p  : Save the queries under this folder instead of the name of the workspace. Ex: --prefix demo/api
+
+gourl import openapi <spec.yaml|spec.json> [--prefix <folder>] [--env <name>]
+
+  Create one query per operation of an OpenAPI 3 specification, saved as <prefix>/<tag>/<operationId>. The url of the server becomes the base_url variable of the current environment, the path parameters become variables, and the required parameters and the request bodies are prefilled with their examples.
+    --prefix, -p  : Save the queries under this folder instead of the title of the api. Ex: --prefix services/billing
+    --env         : Add the base_url variable to this environment instead of the current one. Ex: --env staging
+
+gourl import har <file.har> [--url <pattern>] [--method <method>] [--prefix <folder>]
+
+  Save the requests recorded in a HAR file (exported by the browser devtools), named <prefix>/<host>/<path>. The recorded responses are saved as examples.
+    --url,    -u  : Only import the requests whose url matches this regular expression. Ex: --url api.example.com/v1
+    --method, -m  : Only import the requests using this method. You can use this flag several times. Ex: --method POST
+    --prefix, -p  : Save the queries under this folder instead of "har". Ex: --prefix recordings/checkout`
+}
+
+func (c *ImportCmd) Execute(cmd string, actions []string, flags []Flag) (string, error) {
+	if len(actions) == 0 {
+		return fmt.Sprintf("No action provided. You must provide one of the actions below:\n%s\n", c.GetHelp()), nil
+	}
+
+	filePath := ""
+	saveAs := ""
+	prefix := ""
+	name := ""
+	urlPattern := ""
+	methods := []string{}
+	options := sendOptions{}
+	for _, flag := range flags {
+		if options.parseFlag(flag) {
+			continue
+		}
+		switch flag.Key {
+		case "file":
+			filePath = flag.Value
+		case "save":
+			saveAs = flag.Value
+		case "prefix":
+			prefix = flag.Value
+		case "name":
+			name = flag.Value
+		case "url":
+			urlPattern = flag.Value
+		case "method":
+			methods = append(methods, flag.Value)
+		default:
+			return "", fmt.Errorf("the %s flag is unknown. Use `gourl import` to list all the options", flag.Key)
+		}
+	}
+
+	action := actions[0]
+	// only a curl command which is not saved is sent, and openapi adds variables to an environment
+	isSent := action == "curl" && saveAs == ""
+	if len(options.variables) > 0 && !isSent {
+		return "", fmt.Errorf("the --var flag can only be used to send a curl command")
+	}
+	if options.env != "" && !isSent && action != "openapi" {
+		return "", fmt.Errorf("the --env flag can only be used to send a curl command, or to choose the environment of the openapi variables")
+	}
+	switch action {
+	case "curl":
+		command := strings.Join(actions[1:], " ")
+		if command == "" {
+			content, err := readInput(filePath)
+			if err != nil {
+				return "", err
+			}
+			command = content
+		}
+		query, warnings, err := convert.ParseCurl(command)
+		if err != nil {
+			return "", err
+		}
+
+		res := formatWarnings(warnings)
+		if saveAs == "" {
+			output, err := sendQuery(query, options)
+			if err != nil {
+				return "", err
+			}
+			return res + output, nil
+		}
+
+		query.Name = saveAs
+		err = saveImportedQuery(query)
+		if err != nil {
+			return "", err
+		}
+		return res + fmt.Sprintf("saved as %s. Use `gourl load --name %s` to execute it", saveAs, saveAs), nil
+
+	case "postman":
+		content, err := readImportFile(actions, filePath)
+		if err != nil {
+			return "", err
+		}
+		if prefix == "" {
+			prefix, err = collectionName(content)
+			if err != nil {
+				return "", err
+			}
+		}
+		queries, warnings, err := convert.ParsePostmanCollection(content, prefix)
+		if err != nil {
+			return "", err
+		}
+		return saveImportedQueries(queries, nil, warnings)
+
+	case "postman-env":
+		content, err := readImportFile(actions, filePath)
+		if err != nil {
+			return "", err
+		}
+		env, warnings, err := convert.ParsePostmanEnvironment(content)
+		if err != nil {
+			return "", err
+		}
+		if name != "" {
+			env.Name = name
+		}
+		if env.Name == "" {
+			return "", fmt.Errorf("the environment has no name. Provide one with the --name flag")
+		}
+		created, err := saveImportedEnv(env)
+		if err != nil {
+			return "", err
+		}
+		if !created {
+			return "", fmt.Errorf("the environment %s already exists. Choose another name with the --name flag", env.Name)
+		}
+		return formatWarnings(warnings) + fmt.Sprintf("environment %s created with %d variables. Use `gourl env load --name %s` to activate it", env.Name, len(env.Variables), env.Name), nil
+
+	case "insomnia":
+		content, err := readImportFile(actions, filePath)
+		if err != nil {
+			return "", err
+		}
+		queries, envs, warnings, err := convert.ParseInsomnia(content, prefix)
+		if err != nil {
+			return "", err
+		}
+		envNames := []string{}
+		for _, env := range envs {
+			created, err := saveImportedEnv(&env)
+			if err != nil {
+				return "", err
+			}
+			if !created {
+				warnings = append(warnings, fmt.Sprintf("the environment %s already exists, it is skipped", env.Name))
+				continue
+			}
+			envNames = append(envNames, env.Name)
+		}
+		res, err := saveImportedQueries(queries, nil, warnings)
+		if err != nil {
+			return "", err
+		}
+		if len(envNames) > 0 {
+			res += fmt.Sprintf("\nenvironments created: %s", strings.Join(envNames, ", "))
+		}
+		return res, nil
+
+	case "openapi":
+		content, err := readImportFile(actions, filePath)
+		if err != nil {
+			return "", err
+		}
+		queries, variables, warnings, err := convert.ParseOpenApi(content, prefix)
+		if err != nil {
+			return "", err
+		}
+		if options.env != "" {
+			err := models.UseEnv(options.env)
+			if err != nil {
+				return "", err
+			}
+		}
+		// the variables already set are kept, they may point to another server on purpose
+		addedVariables := []string{}
+		for _, key := range sortedKeys(variables) {
+			if existing, ok := models.CurrentEnv.Variables[key]; ok {
+				if existing != variables[key] {
+					warnings = append(warnings, fmt.Sprintf("the %s variable is kept to %s instead of %s", key, existing, variables[key]))
+				}
+				continue
+			}
+			models.CurrentEnv.Variables[key] = variables[key]
+			addedVariables = append(addedVariables, fmt.Sprintf("%s=%s", key, variables[key]))
+		}
+		if len(addedVariables) > 0 {
+			res := db.Db.Save(&models.CurrentEnv)
+			if res.Error != nil {
+				return "", fmt.Errorf("while saving the variables of the %s environment: %v", models.CurrentEnv.Name, res.Error)
+			}
+		}
+		res, err := saveImportedQueries(queries, nil, warnings)
+		if err != nil {
+			return "", err
+		}
+		if len(addedVariables) > 0 {
+			res += fmt.Sprintf("\nvariables added to the %s environment: %s", models.CurrentEnv.Name, strings.Join(addedVariables, ", "))
+		}
+		return res, nil
+
+	case "har":
+		content, err := readImportFile(actions, filePath)
+		if err != nil {
+			return "", err
+		}
+		if prefix == "" {
+			prefix = "har"
+		}
+		imports, warnings, err := convert.ParseHar(content, prefix, urlPattern, methods)
+		if err != nil {
+			return "", err
+		}
+		queries := []models.Query{}
+		responses := []*models.Response{}
+		for _, imported := range imports {
+			queries = append(queries, imported.Query)
+			responses = append(responses, imported.Response)
+		}
+		return saveImportedQueries(queries, responses, warnings)
+
+	default:
+		return fmt.Sprintf("Invalid action provided (%s). You must provide one of the actions below:\n%s\n", action, c.GetHelp()), nil
+	}
+}
+
+// return the content of the file, or of stdin if no file is provided
+func readInput(filePath string) (string, error) {
+	if filePath != "" {
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			return "", fmt.Errorf("while reading %s: %v", filePath, err)
+		}
+		return string(content), nil
+	}
+	if isInteractive() {
+		return "", fmt.Errorf("nothing to import. Provide it as an argument, with the --file flag or through stdin")
+	}
+	content, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return "", fmt.Errorf("while reading stdin: %v", err)
+	}
+	return string(content), nil
+}
+
+func saveImportedQuery(query *models.Query) error {
+	err := query.Save()
+	if err != nil {
+		if err.Error() == "EXIST-ALREADY" {
+			return fmt.Errorf("a query named %s already exists", query.Name)
+		}
+		return err
+	}
+	return nil
+}
+
+// return the content of the file given as an argument or with the --file flag
+func readImportFile(actions []string, filePath string) ([]byte, error) {
+	if len(actions) > 1 {
+		filePath = actions[1]
+	}
+	content, err := readInput(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(content), nil
+}
+
+// return the name of the collection, used as the default folder of its queries
+func collectionName(content []byte) (string, error) {
+	var collection struct {
+		Info struct {
+			Name string `json:"name"`
+		} `json:"info"`
+	}
+	err := json.Unmarshal(content, &collection)
+	if err != nil {
+		return "", fmt.Errorf("invalid collection: %v", err)
+	}
+	return strings.ReplaceAll(collection.Info.Name, "/", "-"), nil
+}
+
+// save all the queries, skipping the ones already existing, and return a summary.
+// The responses, if any, are saved as the examples of the queries of the same index
+func saveImportedQueries(queries []models.Query, responses []*models.Response, warnings []string) (string, error) {
+	saved := 0
+	examples := 0
+	for index, query := range queries {
+		err := query.Save()
+		if err != nil {
+			if err.Error() == "EXIST-ALREADY" {
+				warnings = append(warnings, fmt.Sprintf("a query named %s already exists, it is skipped", query.Name))
+				continue
+			}
+			return "", err
+		}
+		saved++
+		if index < len(responses) && responses[index] != nil {
+			_, err = models.SaveExample(&query, responses[index])
+			if err != nil {
+				return "", err
+			}
+			examples++
+		}
+	}
+	res := formatWarnings(warnings) + fmt.Sprintf("%d queries imported out of %d. Use `gourl list` to see them", saved, len(queries))
+	if examples > 0 {
+		res += fmt.Sprintf("\n%d recorded responses saved as examples. Use `gourl query examples --name <name>` to see them", examples)
+	}
+	return res, nil
+}
+
+func formatWarnings(warnings []string) string {
+	res := ""
+	for _, warning := range warnings {
+		res += fmt.Sprintf("warning: %s\n", warning)
+	}
+	return res
+}
+
+// create the environment, unless one with the same name already exists
+func saveImportedEnv(env *models.Environment) (bool, error) {
+	existingEnv, err := models.GetEnv(env.Name)
+	if err != nil {
+		return false, err
+	}
+	if existingEnv != nil {
+		return false, nil
+	}
+	return true, models.CreateEnv(env)
+}
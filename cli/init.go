@@ -0,0 +1,60 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/nakurai/gourl/models"
+	"github.com/nakurai/gourl/utils"
+)
+
+type InitCmd struct{}
+
+// return all the commands that will lead to this execution path
+func (c *InitCmd) GetCmds() []string {
+	return []string{
+		"init",
+	}
+}
+
+// return all the flags this cmd can handle
+func (c *InitCmd) GetFlags() []ValidFlag {
+	return []ValidFlag{}
+}
+
+// return all the flags this cmd can handle
+func (c *InitCmd) GetHelp() string {
+	return `
+gourl init [<directory>]
+
+  Create a workspace in the directory (the current one by default), with its own queries and environments. Gourl uses the closest workspace found in the current directory or its parents, like git does, and ~/.gourl otherwise.
+  The workspace can also be chosen with the GOURL_HOME environment variable, or with the --workspace <path> flag of any command.`
+}
+
+func (c *InitCmd) Execute(cmd string, actions []string, flags []Flag) (string, error) {
+	if len(flags) > 0 {
+		return "", fmt.Errorf("the %s flag is unknown. Use `gourl init` to list all the options", flags[0].Key)
+	}
+	dir := "."
+	if len(actions) > 0 {
+		dir = actions[0]
+	}
+
+	dataDirPath := filepath.Join(dir, utils.WorkspaceDirName)
+	exists, err := utils.DirExists(dataDirPath)
+	if err != nil {
+		return "", err
+	}
+	if exists {
+		return "", fmt.Errorf("a workspace already exists in %s", dataDirPath)
+	}
+	err = utils.UseDataDir(dataDirPath, "project")
+	if err != nil {
+		return "", err
+	}
+	err = models.InitWorkspace()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("workspace created in %s. It is used in %s and its sub directories", utils.DataDirPath, filepath.Dir(utils.DataDirPath)), nil
+}
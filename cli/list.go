@@ -1,6 +1,11 @@
 package cli
 
 import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
 	"github.com/nakurai/gourl/models"
 )
 
@@ -15,19 +20,85 @@ func (c *ListCmd) GetCmds() []string {
 
 // return all the flags this cmd can handle
 func (c *ListCmd) GetFlags() []ValidFlag {
-	return []ValidFlag{}
+	return []ValidFlag{
+		{Key: "depth", Labels: []string{"--depth"}},
+		{Key: "prefix", Labels: []string{"-p", "--prefix"}},
+		{Key: "method", Labels: []string{"-m", "--method"}},
+		{Key: "format", Labels: []string{"-f", "--format"}},
+		{Key: "tag", Labels: []string{"-t", "--tag"}},
+	}
 }
 
 // return all the flags this cmd can handle
 func (c *ListCmd) GetHelp() string {
 	return `
-gourl list
+gourl list [--depth <n>] [--prefix <folder>] [--method <method>] [--tag <tag>] [--format text|json]
 
-  List all the queries you have saved.`
+  List all the queries you have saved, sorted by name.
+    --depth       : Number of folder levels to expand. Folders deeper than that are displayed collapsed with their number of queries, 0 collapses all of them. Ex: --depth 1
+    --prefix, -p  : Only list the queries saved under this folder. Ex: --prefix demo/api
+    --method, -m  : Only list the queries using this http method. You can use this flag several times. Ex: --method get -m post
+    --tag,    -t  : Only list the queries having this tag. If you use this flag several times, the queries must have all the tags. Ex: --tag users -t admin
+    --format, -f  : text (default) displays a tree, json outputs the tree as a JSON document for scripting.`
 }
 
 // create and send a new http request based on the provided parameters
 // we are not expecting any actions here
 func (c *ListCmd) Execute(cmd string, actions []string, flags []Flag) (string, error) {
-	return models.QueryTree.Print(-1, ""), nil
+	depth := -1
+	prefix := ""
+	format := "text"
+	methods := map[string]bool{}
+	tags := []string{}
+	for _, flag := range flags {
+		switch flag.Key {
+		case "depth":
+			var err error
+			depth, err = strconv.Atoi(flag.Value)
+			if err != nil || depth < 0 {
+				return "", fmt.Errorf("the --depth flag must be zero or a positive number, not %s", flag.Value)
+			}
+		case "prefix":
+			prefix = strings.Trim(flag.Value, "/")
+		case "method":
+			methods[strings.ToUpper(flag.Value)] = true
+		case "format":
+			format = flag.Value
+		case "tag":
+			tags = append(tags, flag.Value)
+		default:
+			return "", fmt.Errorf("the %s flag is unknown. Use `gourl help` to list all the options", flag.Key)
+		}
+	}
+	if format != "text" && format != "json" {
+		return "", fmt.Errorf("unknown format %s. The --format flag must be text or json", format)
+	}
+
+	tree := models.QueryTree.Find(prefix)
+	if tree == nil {
+		return "", fmt.Errorf("no folder named %s exists", prefix)
+	}
+	tree = tree.Filter(func(leaf models.QueryTreeLeaf) bool {
+		if len(methods) > 0 && !methods[strings.ToUpper(leaf.Method)] {
+			return false
+		}
+		for _, tag := range tags {
+			if !leaf.HasTag(tag) {
+				return false
+			}
+		}
+		return true
+	})
+	if prefix != "" {
+		tree.CurrentNode = prefix + "/"
+	}
+
+	if format == "json" {
+		treeJson, err := json.MarshalIndent(tree.ToJson(depth), "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return string(treeJson), nil
+	}
+	return tree.Print(depth, ""), nil
 }
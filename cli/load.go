@@ -17,36 +17,42 @@ func (c *LoadCmd) GetCmds() []string {
 
 // return all the flags this cmd can handle
 func (c *LoadCmd) GetFlags() []ValidFlag {
-	return []ValidFlag{
+	return append([]ValidFlag{
 		{Key: "name", Labels: []string{"-n", "--name"}},
-		{Key: "verbose", Labels: []string{"-v", "--verbose"}},
-	}
+	}, sendFlags()...)
 }
 
 // return all the flags this cmd can handle
 func (c *LoadCmd) GetHelp() string {
 	return `
-gourl load --name <name> [--verbose true]
+gourl load [--name <name>] [--verbose true] [--save-example true] [--compare-example true] [--var key=value] [--env <name>]
 
-  Load and execute the saved query using the current environment's variables if necessary`
+  Load and execute the saved query using the current environment's variables if necessary.
+  Without --name, an interactive list of the saved queries lets you pick the one to execute (see gourl pick)
+    --name, -n: The full name of the saved query. Ex: --name demo/test/post_message
+` + sendFlagsHelp
 }
 
 func (c *LoadCmd) Execute(cmd string, actions []string, flags []Flag) (string, error) {
 	nameToLoad := ""
-	verbose := false
+	options := sendOptions{}
 	for _, flag := range flags {
+		if options.parseFlag(flag) {
+			continue
+		}
 		switch flag.Key {
 		case "name":
 			nameToLoad = flag.Value
-		case "verbose":
-			verbose = flag.Value == "true"
 		default:
 			return "", fmt.Errorf("the %s flag is unknown. Use `gourl load` to list all the options", flag.Key)
 
 		}
 	}
 	if nameToLoad == "" {
-		return "", fmt.Errorf("the --name flag is mandatory. Use `gourl load` to list all the options")
+		if isInteractive() {
+			return pickAndSend(options)
+		}
+		return "", fmt.Errorf("the --name flag is mandatory when not running in a terminal. Use `gourl help` to list all the options")
 	}
 
 	query, err := models.GetQuery(nameToLoad)
@@ -57,5 +63,5 @@ func (c *LoadCmd) Execute(cmd string, actions []string, flags []Flag) (string, e
 		return "", fmt.Errorf("no query named %s exists", nameToLoad)
 	}
 
-	return query.Send(verbose)
+	return sendQuery(query, options)
 }
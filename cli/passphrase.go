@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/nakurai/gourl/models"
+)
+
+func init() {
+	// the secret variables are decrypted when they are used
+	models.PassphraseProvider = func() (string, error) {
+		return readPassphrase(false)
+	}
+}
+
+// return the passphrase from the GOURL_PASSPHRASE environment variable, from the
+// file of the GOURL_KEY_FILE environment variable, or ask it.
+// If confirm is true, it is asked twice to avoid typos
+func readPassphrase(confirm bool) (string, error) {
+	if passphrase := os.Getenv("GOURL_PASSPHRASE"); passphrase != "" {
+		return passphrase, nil
+	}
+	if keyFile := os.Getenv("GOURL_KEY_FILE"); keyFile != "" {
+		content, err := os.ReadFile(keyFile)
+		if err != nil {
+			return "", fmt.Errorf("while reading the key file of GOURL_KEY_FILE: %v", err)
+		}
+		passphrase := strings.TrimSpace(string(content))
+		if passphrase == "" {
+			return "", fmt.Errorf("the key file %s is empty", keyFile)
+		}
+		return passphrase, nil
+	}
+	return askPassphrase("passphrase", confirm)
+}
+
+// ask the passphrase to the user, the name tells which one, ex: new passphrase
+func askPassphrase(name string, confirm bool) (string, error) {
+	if !canPrompt() {
+		return "", fmt.Errorf("a passphrase is needed. Set it in the GOURL_PASSPHRASE environment variable, or the path of a file holding it in GOURL_KEY_FILE")
+	}
+
+	passphrase, err := promptValue(name+": ", true)
+	if err != nil {
+		return "", err
+	}
+	if passphrase == "" {
+		return "", fmt.Errorf("the passphrase cannot be empty")
+	}
+	if confirm {
+		again, err := promptValue(name+" (again): ", true)
+		if err != nil {
+			return "", err
+		}
+		if again != passphrase {
+			return "", fmt.Errorf("the passphrases do not match")
+		}
+	}
+	return passphrase, nil
+}
+
+// encrypt the value of a new secret variable. The passphrase of the first secret
+// becomes the one of the vault, so it is confirmed
+func encryptSecret(value string) (string, error) {
+	count, err := models.CountSecrets()
+	if err != nil {
+		return "", err
+	}
+	if count == 0 {
+		passphrase, err := readPassphrase(true)
+		if err != nil {
+			return "", err
+		}
+		models.PassphraseProvider = func() (string, error) { return passphrase, nil }
+	}
+	return models.EncryptSecret(value)
+}
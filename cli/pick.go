@@ -0,0 +1,280 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/nakurai/gourl/models"
+	"github.com/nakurai/gourl/utils"
+	"golang.org/x/term"
+)
+
+type PickCmd struct{}
+
+// return all the commands that will lead to this execution path
+func (c *PickCmd) GetCmds() []string {
+	return []string{
+		"pick",
+	}
+}
+
+// return all the flags this cmd can handle
+func (c *PickCmd) GetFlags() []ValidFlag {
+	return sendFlags()
+}
+
+// return all the flags this cmd can handle
+func (c *PickCmd) GetHelp() string {
+	return `
+gourl pick [--verbose true] [--save-example true] [--compare-example true] [--var key=value] [--env <name>]
+
+  Interactively pick a saved query and execute it. Type to fuzzy search the queries, use the up and down arrows to select one and press enter to execute it. Escape cancels. This is also what ` + "`gourl load`" + ` does when no name is provided.
+` + sendFlagsHelp
+}
+
+func (c *PickCmd) Execute(cmd string, actions []string, flags []Flag) (string, error) {
+	options := sendOptions{}
+	for _, flag := range flags {
+		if !options.parseFlag(flag) {
+			return "", fmt.Errorf("the %s flag is unknown. Use `gourl pick` to list all the options", flag.Key)
+		}
+	}
+	return pickAndSend(options)
+}
+
+// return true if the user can interact with the program through a terminal
+func isInteractive() bool {
+	return term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// let the user pick a saved query, then send it
+func pickAndSend(options sendOptions) (string, error) {
+	if !isInteractive() {
+		return "", fmt.Errorf("picking a query requires a terminal. Use `gourl load --name <name>` instead")
+	}
+	leaves := models.QueryTree.AllLeaves()
+	if len(leaves) == 0 {
+		return "no saved query to pick from. Use the --save flag to save a query", nil
+	}
+
+	p := picker{
+		leaves:  leaves,
+		queries: map[string]*models.Query{},
+	}
+	leaf, err := p.run()
+	if err != nil {
+		return "", err
+	}
+	if leaf == nil {
+		return "cancelled.", nil
+	}
+
+	query, err := p.getQuery(leaf.Path)
+	if err != nil {
+		return "", err
+	}
+	if query == nil {
+		return "", fmt.Errorf("no query named %s exists", leaf.Path)
+	}
+	return sendQuery(query, options)
+}
+
+// interactive list of the saved queries, filtered by what the user types
+type picker struct {
+	leaves   []models.QueryTreeLeaf
+	filter   string
+	matches  []models.QueryTreeLeaf
+	selected int
+	queries  map[string]*models.Query // queries already fetched for the preview, by name
+}
+
+// display the picker until the user selects a query or cancels.
+// It returns nil if the user cancelled.
+func (p *picker) run() (*models.QueryTreeLeaf, error) {
+	stdinFd := int(os.Stdin.Fd())
+	oldState, err := term.MakeRaw(stdinFd)
+	if err != nil {
+		return nil, fmt.Errorf("while switching the terminal to raw mode: %v", err)
+	}
+	// the alternate screen keeps the terminal content intact once we are done
+	fmt.Print("\033[?1049h")
+	defer func() {
+		fmt.Print("\033[?1049l")
+		term.Restore(stdinFd, oldState)
+	}()
+
+	p.applyFilter()
+	buf := make([]byte, 16)
+	for {
+		p.render()
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+		input := buf[:n]
+		switch {
+		case input[0] == 3 || (n == 1 && input[0] == 27): // ctrl+c, escape
+			return nil, nil
+		case input[0] == 13 || input[0] == 10: // enter
+			if len(p.matches) == 0 {
+				continue
+			}
+			return &p.matches[p.selected], nil
+		case n >= 3 && input[0] == 27 && input[1] == '[' && input[2] == 'A', input[0] == 16: // up arrow, ctrl+p
+			if p.selected > 0 {
+				p.selected -= 1
+			}
+		case n >= 3 && input[0] == 27 && input[1] == '[' && input[2] == 'B', input[0] == 14: // down arrow, ctrl+n
+			if p.selected < len(p.matches)-1 {
+				p.selected += 1
+			}
+		case input[0] == 127 || input[0] == 8: // backspace
+			if p.filter != "" {
+				filterRunes := []rune(p.filter)
+				p.filter = string(filterRunes[:len(filterRunes)-1])
+				p.applyFilter()
+			}
+		case input[0] == 21: // ctrl+u
+			p.filter = ""
+			p.applyFilter()
+		case input[0] >= 32 && input[0] != 27:
+			p.filter += string(input)
+			p.applyFilter()
+		}
+	}
+}
+
+// compute the list of queries matching the filter, best matches first
+func (p *picker) applyFilter() {
+	p.selected = 0
+	if p.filter == "" {
+		p.matches = p.leaves
+		return
+	}
+	type scoredLeaf struct {
+		leaf  models.QueryTreeLeaf
+		score int
+	}
+	scored := []scoredLeaf{}
+	for _, leaf := range p.leaves {
+		score, ok := utils.FuzzyScore(p.filter, leaf.Path)
+		if ok {
+			scored = append(scored, scoredLeaf{leaf: leaf, score: score})
+		}
+	}
+	sort.SliceStable(scored, func(i, j int) bool {
+		return scored[i].score > scored[j].score
+	})
+	p.matches = []models.QueryTreeLeaf{}
+	for _, s := range scored {
+		p.matches = append(p.matches, s.leaf)
+	}
+}
+
+func (p *picker) getQuery(name string) (*models.Query, error) {
+	query, ok := p.queries[name]
+	if ok {
+		return query, nil
+	}
+	query, err := models.GetQuery(name)
+	if err != nil {
+		return nil, err
+	}
+	p.queries[name] = query
+	return query, nil
+}
+
+// draw the filter, the list of matches and the preview of the selected query
+func (p *picker) render() {
+	width, height, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil || width <= 0 || height <= 0 {
+		width, height = 80, 24
+	}
+	preview := p.preview()
+	if len(preview) > height/3 {
+		preview = preview[:height/3]
+	}
+	// the prompt, the separator and the preview take some room, the list gets the rest
+	listHeight := height - len(preview) - 3
+	if listHeight < 3 {
+		listHeight = 3
+	}
+
+	// in raw mode, lines must end with \r\n
+	screen := "\033[H\033[2J"
+	screen += fmt.Sprintf("%d/%d > %s\r\n", len(p.matches), len(p.leaves), p.filter)
+
+	// scroll the list so the selected query is always visible
+	first := 0
+	if p.selected >= listHeight {
+		first = p.selected - listHeight + 1
+	}
+	for index := first; index < len(p.matches) && index < first+listHeight; index++ {
+		leaf := p.matches[index]
+		line := truncate(fmt.Sprintf("%-7s %s", leaf.Method, leaf.Path), width-2)
+		if index == p.selected {
+			screen += "\033[7m> " + line + "\033[0m\r\n"
+		} else {
+			screen += "  " + line + "\r\n"
+		}
+	}
+	screen += strings.Repeat("─", width) + "\r\n"
+	for _, line := range preview {
+		screen += truncate(line, width) + "\r\n"
+	}
+	fmt.Print(screen)
+	// put the cursor back after the filter
+	fmt.Printf("\033[1;%dH", len(fmt.Sprintf("%d/%d > ", len(p.matches), len(p.leaves)))+len([]rune(p.filter))+1)
+}
+
+// return the lines describing the selected query
+func (p *picker) preview() []string {
+	if len(p.matches) == 0 {
+		return []string{"no query matches " + p.filter}
+	}
+	query, err := p.getQuery(p.matches[p.selected].Path)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	if query == nil {
+		return []string{"query not found"}
+	}
+	lines := []string{
+		fmt.Sprintf("%s %s", query.Method, query.Url),
+	}
+	for _, key := range sortedKeys(query.Header) {
+		lines = append(lines, fmt.Sprintf("  %s: %s", key, query.Header[key]))
+	}
+	for _, key := range sortedKeys(query.Cookie) {
+		lines = append(lines, fmt.Sprintf("  cookie %s=%s", key, query.Cookie[key]))
+	}
+	for _, key := range sortedKeys(query.Data) {
+		lines = append(lines, fmt.Sprintf("  data %s=%s", key, query.Data[key]))
+	}
+	if query.Body != "" {
+		lines = append(lines, fmt.Sprintf("  body: %d bytes", len(query.Body)))
+	}
+	return lines
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func truncate(s string, width int) string {
+	runes := []rune(s)
+	if width <= 0 || len(runes) <= width {
+		return s
+	}
+	if width == 1 {
+		return "…"
+	}
+	return string(runes[:width-1]) + "…"
+}
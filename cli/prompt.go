@@ -0,0 +1,37 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+var stdinReader = bufio.NewReader(os.Stdin)
+
+// return true if the user can answer questions. Unlike isInteractive, the output
+// can be redirected since the questions are written on stderr
+func canPrompt() bool {
+	return term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// ask a value to the user. If hidden is true, what is typed is not displayed.
+// The prompt goes to stderr, so the output of the command can still be redirected
+func promptValue(prompt string, hidden bool) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	if hidden {
+		value, err := term.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", fmt.Errorf("while reading the answer: %v", err)
+		}
+		return string(value), nil
+	}
+	value, err := stdinReader.ReadString('\n')
+	if err != nil {
+		return "", fmt.Errorf("while reading the answer: %v", err)
+	}
+	return strings.TrimRight(value, "\r\n"), nil
+}
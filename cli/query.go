@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/nakurai/gourl/models"
+)
+
+type QueryCmd struct{}
+
+// return all the commands that will lead to this execution path
+func (c *QueryCmd) GetCmds() []string {
+	return []string{
+		"query",
+	}
+}
+
+// return all the flags this cmd can handle
+func (c *QueryCmd) GetFlags() []ValidFlag {
+	return []ValidFlag{
+		{Key: "name", Labels: []string{"-n", "--name"}},
+		{Key: "verbose", Labels: []string{"-v", "--verbose"}},
+	}
+}
+
+// return all the flags this cmd can handle
+func (c *QueryCmd) GetHelp() string {
+	return `
+gourl query examples --name <name> [--verbose true]
+
+  List the example responses stored for a saved query, the most recent first. Examples are stored with the --save-example flag.
+	--name,    -n: The full name of the saved query.
+	--verbose, -v: If true, the headers and body of each example are displayed.`
+}
+
+func (c *QueryCmd) Execute(cmd string, actions []string, flags []Flag) (string, error) {
+	nbActions := len(actions)
+	if nbActions == 0 {
+		return fmt.Sprintf("No action provided. You must provide one of the actions below:\n%s\n", c.GetHelp()), nil
+	}
+	if nbActions > 1 {
+		return fmt.Sprintf("Too many actions provided (%d). You must provide one of the actions below:\n%s\n", nbActions, c.GetHelp()), nil
+	}
+
+	action := actions[0]
+	switch action {
+	case "examples":
+		name := ""
+		verbose := false
+		for _, flag := range flags {
+			switch flag.Key {
+			case "name":
+				name = flag.Value
+			case "verbose":
+				verbose = flag.Value == "true"
+			default:
+				return "", fmt.Errorf("the %s flag is unknown. Use `gourl query` to list all the options", flag.Key)
+			}
+		}
+		if name == "" {
+			return "", fmt.Errorf("the --name flag is mandatory. Use `gourl query` to list all the options")
+		}
+
+		query, err := models.GetQuery(name)
+		if err != nil {
+			return "", err
+		}
+		if query == nil {
+			return "", fmt.Errorf("no query named %s exists", name)
+		}
+		examples, err := models.GetExamples(query.ID)
+		if err != nil {
+			return "", err
+		}
+		if len(examples) == 0 {
+			return fmt.Sprintf("no example saved for %s. Use `gourl load --name %s --save-example true` to store one", name, name), nil
+		}
+
+		res := ""
+		for _, example := range examples {
+			res += fmt.Sprintf("%s  %s  %d bytes\n", example.CreatedAt.Format("2006-01-02 15:04:05"), example.Status, len(example.Body))
+			if verbose {
+				res += "headers:\n"
+				for _, key := range sortedKeys(example.Header) {
+					res += fmt.Sprintf("  %s: %s\n", key, example.Header[key])
+				}
+				res += fmt.Sprintf("body:\n%s\n\n", example.PrettyBody())
+			}
+		}
+		return res, nil
+
+	default:
+		return fmt.Sprintf("Invalid action provided (%s). You must provide one of the actions below:\n%s\n", action, c.GetHelp()), nil
+	}
+}
@@ -28,22 +28,23 @@ func (c *RequestCmd) GetCmds() []string {
 
 // return all the flags this cmd can handle
 func (c *RequestCmd) GetFlags() []ValidFlag {
-	return []ValidFlag{
+	return append([]ValidFlag{
 		{Key: "data", Labels: []string{"-d", "--data"}},
 		{Key: "header", Labels: []string{"-h", "--header"}},
 		{Key: "json", Labels: []string{"-j", "--json"}},
 		{Key: "save", Labels: []string{"-s", "--save"}},
 		{Key: "url", Labels: []string{"-u", "--url"}},
 		{Key: "cookie", Labels: []string{"-c", "--cookie"}},
-		{Key: "verbose", Labels: []string{"-v", "--verbose"}},
-	}
+		{Key: "description", Labels: []string{"-e", "--description"}},
+		{Key: "tag", Labels: []string{"-t", "--tag"}},
+	}, sendFlags()...)
 }
 
 
 // return all the flags this cmd can handle
 func (c *RequestCmd) GetHelp() string {
 	return `
-gourl connect|delete|get|head|options|patch|post|put|trace --url <url> [--data test=test] [--header test=test] [--json true|false] [--save <name>] [--verbose true]
+gourl connect|delete|get|head|options|patch|post|put|trace --url <url> [--data test=test] [--header test=test] [--json true|false] [--save <name> [--description <text>] [--tag <tag>]] [--verbose true] [--save-example true] [--compare-example true] [--var key=value] [--env <name>]
 
   Send a request to the url provided via the --url flags. List of flags are:
     --url,    -u: The URL you want to send the request to. This flag is mandatory. Ex: --url https://example.com
@@ -51,15 +52,18 @@ gourl connect|delete|get|head|options|patch|post|put|trace --url <url> [--data t
     --header, -h: You can specify the request header. Format is like the --data flag: key=value. Ex: Authentication="Bearer XYZ"
     --json,   -j: If the value is true, then the body will be formatted as a JSON object and the content-type header will be set to application/json (if no content type header was explictly provided)
     --save,   -s: You can provide any name here and the query will be save alongside with all the flags. If a query with the same name already exists, it will let you know and not save it.
+    --description, -e: Used with --save, explains what the query is for. It is displayed by gourl docs.
+    --tag,    -t: Used with --save, adds a tag to the query. You can use this flag several times. Tags can be used to filter gourl list and gourl docs. Ex: --tag users -t admin
     --cookie, -c: Just like for data and headers, you can specify the request cookies.
-    --verbose, -v: If true, then it will display more information about the query, otherwise, only the response body.`
+` + sendFlagsHelp
 }
 
 
 // create and send a new http request based on the provided parameters
 // we are not expecting any actions here
 func (c *RequestCmd) Execute(cmd string, actions []string, flags []Flag) (string, error) {
-	verbose := false
+	options := sendOptions{}
+	tags := []string{}
 	newQuery := models.Query{
 		Method: strings.ToUpper(cmd),
 		Data:   map[string]string{},
@@ -67,23 +71,38 @@ func (c *RequestCmd) Execute(cmd string, actions []string, flags []Flag) (string
 		Cookie: map[string]string{},
 	}
 	for _, flag := range flags {
+		if options.parseFlag(flag) {
+			continue
+		}
 		switch flag.Key {
 		case "data":
-			dataParts := strings.Split(flag.Value, "=")
-			dataKey := strings.ToLower(dataParts[0])
-			newQuery.Data[dataKey] = strings.Join(dataParts, "=")
+			dataKey, dataValue, err := splitKeyValue(flag.Key, flag.Value)
+			if err != nil {
+				return "", err
+			}
+			newQuery.Data[strings.ToLower(dataKey)] = dataValue
 		case "header":
-			dataParts := strings.Split(flag.Value, "=")
-			dataKey := strings.ToLower(dataParts[0])
-			newQuery.Header[dataKey] = strings.Join(dataParts, "=")
+			dataKey, dataValue, err := splitKeyValue(flag.Key, flag.Value)
+			if err != nil {
+				return "", err
+			}
+			newQuery.Header[strings.ToLower(dataKey)] = dataValue
+		case "cookie":
+			dataKey, dataValue, err := splitKeyValue(flag.Key, flag.Value)
+			if err != nil {
+				return "", err
+			}
+			newQuery.Cookie[dataKey] = dataValue
 		case "json":
 			newQuery.IsJson = flag.Value == "true"
 		case "save":
 			newQuery.Name = flag.Value
 		case "url":
 			newQuery.Url = flag.Value
-		case "verbose":
-			verbose = flag.Value == "true"
+		case "description":
+			newQuery.Description = flag.Value
+		case "tag":
+			tags = append(tags, flag.Value)
 		default:
 			return "", fmt.Errorf("unknown flag %s. Use gourl help for a list of valid flags", flag.Key)
 
@@ -102,6 +121,8 @@ func (c *RequestCmd) Execute(cmd string, actions []string, flags []Flag) (string
 		}
 	}
 
+	newQuery.Tags = strings.Join(tags, ",")
+
 	if newQuery.Url == "" {
 		return "", fmt.Errorf("no url provided. Please specify a url by using the '--url' flag")
 	}
@@ -121,6 +142,6 @@ func (c *RequestCmd) Execute(cmd string, actions []string, flags []Flag) (string
 
 	}
 
-	return newQuery.Send(verbose)
+	return sendQuery(&newQuery, options)
 
 }
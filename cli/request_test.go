@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequestFlags(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		fmt.Fprintf(w, "query=%s body=%s cookie=%s", r.URL.RawQuery, body, r.Header.Get("Cookie"))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		cmd      string
+		flags    []Flag
+		expected string
+	}{
+		{"get", []Flag{{Key: "data", Value: "token=a=b"}}, "query=token=a%3Db body= cookie="},
+		{"get", []Flag{{Key: "cookie", Value: "session=a=b"}}, "query= body= cookie=session=a=b"},
+		{"post", []Flag{{Key: "data", Value: "token=a=b"}}, "query= body=token=a%3Db cookie="},
+	}
+	for _, test := range tests {
+		flags := append([]Flag{{Key: "url", Value: server.URL}}, test.flags...)
+		res, err := (&RequestCmd{}).Execute(test.cmd, nil, flags)
+		if err != nil {
+			t.Errorf("%v\n", err)
+			continue
+		}
+		if strings.TrimSpace(res) != test.expected {
+			t.Errorf("%s %v should send %s, not %s\n", test.cmd, test.flags, test.expected, res)
+		}
+	}
+}
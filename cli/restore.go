@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/nakurai/gourl/models"
+)
+
+type RestoreCmd struct{}
+
+// return all the commands that will lead to this execution path
+func (c *RestoreCmd) GetCmds() []string {
+	return []string{
+		"restore",
+	}
+}
+
+// return all the flags this cmd can handle
+func (c *RestoreCmd) GetFlags() []ValidFlag {
+	return []ValidFlag{
+		{Key: "strategy", Labels: []string{"--strategy"}},
+	}
+}
+
+// return all the flags this cmd can handle
+func (c *RestoreCmd) GetHelp() string {
+	return `
+gourl restore <bundle.tar.gz> [--strategy skip|overwrite|rename]
+
+  Restore the saved queries, environments and example responses of a bundle written by gourl backup. If the secrets of the bundle are encrypted, the passphrase is read from the GOURL_PASSPHRASE environment variable, or asked.
+    --strategy    : What to do with the queries and environments which already exist: skip them (default), overwrite them, or restore them under another name, ex: users/list (restored).`
+}
+
+func (c *RestoreCmd) Execute(cmd string, actions []string, flags []Flag) (string, error) {
+	if len(actions) == 0 {
+		return fmt.Sprintf("No bundle provided. You must use the command as below:\n%s\n", c.GetHelp()), nil
+	}
+
+	strategy := models.RestoreSkip
+	for _, flag := range flags {
+		switch flag.Key {
+		case "strategy":
+			strategy = flag.Value
+		default:
+			return "", fmt.Errorf("the %s flag is unknown. Use `gourl restore` to list all the options", flag.Key)
+		}
+	}
+
+	bundlePath := actions[0]
+	file, err := os.Open(bundlePath)
+	if err != nil {
+		return "", fmt.Errorf("while opening %s: %v", bundlePath, err)
+	}
+	defer file.Close()
+	bundle, err := models.ReadBundle(file)
+	if err != nil {
+		return "", err
+	}
+
+	if bundle.Manifest.Secrets == models.SecretsEncrypt {
+		passphrase, err := readPassphrase(false)
+		if err != nil {
+			return "", err
+		}
+		err = bundle.DecryptSecrets(passphrase)
+		if err != nil {
+			return "", err
+		}
+	}
+	report, err := bundle.Restore(strategy)
+	if err != nil {
+		return "", err
+	}
+
+	res := fmt.Sprintf("%s\nrestored from the bundle of %s", report, bundle.Manifest.CreatedAt.Local().Format("2006-01-02 15:04:05"))
+	if bundle.Manifest.Secrets == models.SecretsStrip && bundle.Manifest.Counts["secrets"] > 0 {
+		res += fmt.Sprintf("\nthe %d secret variables of the bundle have no value, set them with gourl var add", bundle.Manifest.Counts["secrets"])
+	}
+	return res, nil
+}
@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/nakurai/gourl/convert"
+)
+
+type RunCmd struct{}
+
+// return all the commands that will lead to this execution path
+func (c *RunCmd) GetCmds() []string {
+	return []string{
+		"run",
+	}
+}
+
+// return all the flags this cmd can handle
+func (c *RunCmd) GetFlags() []ValidFlag {
+	return append([]ValidFlag{
+		{Key: "request", Labels: []string{"--request"}},
+	}, sendFlags()...)
+}
+
+// return all the flags this cmd can handle
+func (c *RunCmd) GetHelp() string {
+	return `
+gourl run <file.http> [--request <name>] [--verbose true]
+
+  Execute the requests of a .http or .rest file (VS Code REST Client and JetBrains format) one after the other. The {{var}} variables are defined in the file (@var = value) or in the current environment.
+    --request     : Only execute the request with this name (its # @name annotation or the text following ###). Ex: --request createUser
+` + sendFlagsHelp
+}
+
+func (c *RunCmd) Execute(cmd string, actions []string, flags []Flag) (string, error) {
+	if len(actions) == 0 {
+		return fmt.Sprintf("No file provided. You must use the command as below:\n%s\n", c.GetHelp()), nil
+	}
+
+	requestName := ""
+	options := sendOptions{}
+	for _, flag := range flags {
+		if options.parseFlag(flag) {
+			continue
+		}
+		switch flag.Key {
+		case "request":
+			requestName = flag.Value
+		default:
+			return "", fmt.Errorf("the %s flag is unknown. Use `gourl run` to list all the options", flag.Key)
+		}
+	}
+
+	filePath := actions[0]
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return "", fmt.Errorf("while reading %s: %v", filePath, err)
+	}
+	queries, warnings, err := convert.ParseHttpFile(string(content), filepath.Dir(filePath))
+	if err != nil {
+		return "", err
+	}
+
+	if len(queries) == 0 {
+		return "", fmt.Errorf("no request found in %s", filePath)
+	}
+
+	res := formatWarnings(warnings)
+	names := []string{}
+	executed := 0
+	for _, query := range queries {
+		names = append(names, query.Name)
+		if requestName != "" && query.Name != requestName {
+			continue
+		}
+		executed++
+		res += fmt.Sprintf("### %s\n", query.Name)
+		output, err := sendQuery(&query, options)
+		if err != nil {
+			// the following requests are still executed, they may not depend on this one
+			res += fmt.Sprintf("error: %v\n", err)
+			continue
+		}
+		res += output
+	}
+	if executed == 0 {
+		return "", fmt.Errorf("no request named %s in %s. The requests are: %s", requestName, filePath, strings.Join(names, ", "))
+	}
+	return res, nil
+}
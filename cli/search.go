@@ -0,0 +1,96 @@
+package cli
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/nakurai/gourl/models"
+)
+
+type SearchCmd struct{}
+
+// return all the commands that will lead to this execution path
+func (c *SearchCmd) GetCmds() []string {
+	return []string{
+		"search",
+	}
+}
+
+// return all the flags this cmd can handle
+func (c *SearchCmd) GetFlags() []ValidFlag {
+	return append([]ValidFlag{
+		{Key: "mode", Labels: []string{"--mode"}},
+		{Key: "run", Labels: []string{"-r", "--run"}},
+	}, sendFlags()...)
+}
+
+// return all the flags this cmd can handle
+func (c *SearchCmd) GetHelp() string {
+	return `
+gourl search <text> [--mode substring|regex|fuzzy] [--run <number> [--verbose true]]
+
+  Search the saved queries whose name, url, headers or data match the text. The best matches are listed first.
+    --mode        : substring (default) looks for the text as is, regex uses the text as a regular expression, fuzzy matches the characters of the text in the same order. Ex: --mode fuzzy
+    --run,    -r  : Execute the match with this number in the list of results. Ex: --run 1. The flags of gourl load can be used along with it.`
+}
+
+// search the saved queries and either list the matches or execute one of them
+func (c *SearchCmd) Execute(cmd string, actions []string, flags []Flag) (string, error) {
+	mode := "substring"
+	runIndex := 0
+	options := sendOptions{}
+	for _, flag := range flags {
+		if options.parseFlag(flag) {
+			continue
+		}
+		switch flag.Key {
+		case "mode":
+			mode = flag.Value
+		case "run":
+			var err error
+			runIndex, err = strconv.Atoi(flag.Value)
+			if err != nil || runIndex < 1 {
+				return "", fmt.Errorf("the --run flag must be the number of a result, not %s", flag.Value)
+			}
+		default:
+			return "", fmt.Errorf("the %s flag is unknown. Use `gourl help` to list all the options", flag.Key)
+		}
+	}
+	text := strings.Join(actions, " ")
+	if text == "" {
+		return "", fmt.Errorf("no text to search. Use `gourl search <text>`")
+	}
+
+	queries, err := models.GetAllQueries()
+	if err != nil {
+		return "", err
+	}
+	results, err := models.SearchQueries(queries, text, mode)
+	if err != nil {
+		return "", err
+	}
+	if len(results) == 0 {
+		return fmt.Sprintf("no saved query matches %s", text), nil
+	}
+
+	if runIndex > 0 {
+		if runIndex > len(results) {
+			return "", fmt.Errorf("there are only %d results, impossible to run the number %d", len(results), runIndex)
+		}
+		return sendQuery(&results[runIndex-1].Query, options)
+	}
+
+	res := ""
+	for index, result := range results {
+		folder := path.Dir(result.Query.Name)
+		if folder == "." {
+			folder = "/"
+		}
+		res += fmt.Sprintf("%d. %s (%s) in %s - matched %s\n", index+1, result.Query.Name, result.Query.Method, folder, strings.Join(result.MatchedFields, ", "))
+		res += fmt.Sprintf("   %s\n", result.Query.Url)
+	}
+	res += fmt.Sprintf("\nuse `gourl search %q --run <number>` to execute one of them", text)
+	return res, nil
+}
@@ -0,0 +1,181 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nakurai/gourl/db"
+	"github.com/nakurai/gourl/models"
+	"github.com/nakurai/gourl/utils"
+)
+
+// options shared by all the commands sending a query
+type sendOptions struct {
+	verbose        bool
+	saveExample    bool
+	compareExample bool
+	variables      []string // key=value, used instead of the variables of the environment
+	env            string   // the environment to use instead of the current one
+}
+
+// return the flags shared by all the commands sending a query
+func sendFlags() []ValidFlag {
+	return []ValidFlag{
+		{Key: "verbose", Labels: []string{"-v", "--verbose"}},
+		{Key: "save-example", Labels: []string{"--save-example"}},
+		{Key: "compare-example", Labels: []string{"--compare-example"}},
+		{Key: "var", Labels: []string{"--var"}},
+		{Key: "env", Labels: []string{"--env"}},
+	}
+}
+
+// help of the flags shared by all the commands sending a query
+const sendFlagsHelp = `    --verbose, -v: If true, then it will display more information about the query, otherwise, only the response body.
+    --save-example: If true, the response (status, headers and body) is stored as an example of the saved query. Examples are listed by gourl query examples and rendered by gourl docs.
+    --compare-example: If true, the response is compared with the most recent example of the saved query and the differences are displayed.
+    --var: A variable used for this execution only, instead of the one of the current environment, which is not modified. Can be repeated. Ex: --var id=12
+    --env: The environment to use for this execution only, the current environment stays the same. The GOURL_ENV environment variable does the same for all the commands. Ex: --env staging`
+
+// set the option matching the flag. It returns false if the flag is not a send option
+func (o *sendOptions) parseFlag(flag Flag) bool {
+	switch flag.Key {
+	case "verbose":
+		o.verbose = flag.Value == "true"
+	case "save-example":
+		o.saveExample = flag.Value == "true"
+	case "compare-example":
+		o.compareExample = flag.Value == "true"
+	case "var":
+		o.variables = append(o.variables, flag.Value)
+	case "env":
+		o.env = flag.Value
+	default:
+		return false
+	}
+	return true
+}
+
+// send the query and format its response according to the options
+func sendQuery(query *models.Query, options sendOptions) (string, error) {
+	var example *models.Example
+	if options.compareExample {
+		if query.ID == 0 {
+			return "", fmt.Errorf("only saved queries can be compared with an example")
+		}
+		var err error
+		example, err = models.GetLatestExample(query.ID)
+		if err != nil {
+			return "", err
+		}
+		if example == nil {
+			return "", fmt.Errorf("the query %s does not have any example. Use --save-example true to store one", query.Name)
+		}
+	}
+
+	if options.env != "" {
+		err := models.UseEnv(options.env)
+		if err != nil {
+			return "", err
+		}
+	}
+	for _, variable := range options.variables {
+		key, value, ok := strings.Cut(variable, "=")
+		if !ok || key == "" {
+			return "", fmt.Errorf("wrong formatting %s. The --var flag must be --var key=value", variable)
+		}
+		models.TemporaryVariables[key] = value
+	}
+	err := askMissingVariables(query)
+	if err != nil {
+		return "", err
+	}
+	models.ResetGeneratedValues()
+	res, err := query.Do()
+	if err != nil {
+		return "", err
+	}
+	output, err := res.Format(options.verbose)
+	if err != nil {
+		return "", err
+	}
+	if options.verbose {
+		// the generated values are displayed so the query can be sent again with the same ones
+		generated := ""
+		for _, value := range models.GeneratedValues {
+			generated += fmt.Sprintf("generated: %s = %s\n", value.Name, value.Value)
+		}
+		output = fmt.Sprintf("workspace: %s (%s)\nenvironment: %s\n", utils.DataDirPath, utils.DataDirSource, models.CurrentEnv.Name) + generated + output
+		output = models.MaskSecrets(output)
+	}
+
+	if options.saveExample {
+		_, err := models.SaveExample(query, res)
+		if err != nil {
+			return "", err
+		}
+		output += fmt.Sprintf("\nresponse saved as an example of %s\n", query.Name)
+	}
+
+	if example != nil {
+		diff := example.Compare(res)
+		if len(diff) == 0 {
+			output += fmt.Sprintf("\nthe response matches the example saved on %s\n", example.CreatedAt.Format("2006-01-02 15:04:05"))
+		} else {
+			output += fmt.Sprintf("\ndifferences with the example saved on %s (- example, + response):\n%s\n", example.CreatedAt.Format("2006-01-02 15:04:05"), strings.Join(diff, "\n"))
+		}
+	}
+	return output, nil
+}
+
+// ask the value of the variables missing from the current environment, and offer to
+// save them. Without a terminal, they are all listed in the error
+func askMissingVariables(query *models.Query) error {
+	asked := []string{}
+	for {
+		missing, err := query.MissingVariables()
+		if err != nil {
+			return err
+		}
+		if len(missing) == 0 {
+			break
+		}
+		if !canPrompt() {
+			return fmt.Errorf("missing variables in the %s environment: %s. Use `gourl var add --data key=value` to define them", models.CurrentEnv.Name, strings.Join(missing, ", "))
+		}
+		for _, name := range missing {
+			// the secrets are not displayed while they are typed
+			value, err := promptValue(fmt.Sprintf("value of %s: ", name), utils.IsSecretName(name))
+			if err != nil {
+				return err
+			}
+			models.TemporaryVariables[name] = value
+			asked = append(asked, name)
+		}
+	}
+	if len(asked) == 0 {
+		return nil
+	}
+
+	answer, err := promptValue(fmt.Sprintf("save %s in the %s environment? [y/N] ", strings.Join(asked, ", "), models.CurrentEnv.Name), false)
+	if err != nil {
+		return err
+	}
+	if strings.EqualFold(strings.TrimSpace(answer), "y") || strings.EqualFold(strings.TrimSpace(answer), "yes") {
+		for _, name := range asked {
+			value := models.TemporaryVariables[name]
+			// the secrets are saved encrypted, as with gourl var add --secret true
+			if utils.IsSecretName(name) {
+				value, err = encryptSecret(value)
+				if err != nil {
+					return fmt.Errorf("while encrypting %s: %v", name, err)
+				}
+			}
+			models.CurrentEnv.Variables[name] = value
+		}
+		res := db.Db.Save(&models.CurrentEnv)
+		if res.Error != nil {
+			return fmt.Errorf("while saving the variables: %v", res.Error)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/nakurai/gourl/models"
+)
+
+// the directory used when no --dir flag is provided
+const defaultCollectionDir = "gourl-collection"
+
+type SyncCmd struct{}
+
+// return all the commands that will lead to this execution path
+func (c *SyncCmd) GetCmds() []string {
+	return []string{
+		"sync",
+	}
+}
+
+// return all the flags this cmd can handle
+func (c *SyncCmd) GetFlags() []ValidFlag {
+	return []ValidFlag{
+		{Key: "dir", Labels: []string{"--dir"}},
+		{Key: "prune", Labels: []string{"--prune"}},
+	}
+}
+
+// return all the flags this cmd can handle
+func (c *SyncCmd) GetHelp() string {
+	return `
+gourl sync push [--dir <directory>]
+
+  Write each saved query and each environment as a YAML file, so they can be reviewed and shared with git. A query named demo/users/list is written in <directory>/queries/demo/users/list.yaml, an environment named staging in <directory>/environments/staging.yaml. The files of the deleted queries and environments are removed. The values of the secret variables, judging by their name, and of the encrypted ones are written as <secret>.
+    --dir         : The directory of the collection. Default: ./` + defaultCollectionDir + `
+
+gourl sync pull [--dir <directory>] [--prune true]
+
+  Update the saved queries and environments with the YAML files of the directory, ex: after a git pull. The secret variables written as <secret> keep their saved value.
+    --dir         : The directory of the collection. Default: ./` + defaultCollectionDir + `
+    --prune       : Delete the queries and environments without a file, except the current environment. Default: false`
+}
+
+func (c *SyncCmd) Execute(cmd string, actions []string, flags []Flag) (string, error) {
+	if len(actions) == 0 {
+		return fmt.Sprintf("No action provided. You must provide one of the actions below:\n%s\n", c.GetHelp()), nil
+	}
+
+	dir := defaultCollectionDir
+	prune := false
+	for _, flag := range flags {
+		switch flag.Key {
+		case "dir":
+			dir = flag.Value
+		case "prune":
+			prune = flag.Value == "true"
+		default:
+			return "", fmt.Errorf("the %s flag is unknown. Use `gourl sync` to list all the options", flag.Key)
+		}
+	}
+
+	action := actions[0]
+	switch action {
+	case "push":
+		queries, err := models.GetAllQueries()
+		if err != nil {
+			return "", err
+		}
+		envs, err := models.GetAllEnvs()
+		if err != nil {
+			return "", err
+		}
+		report, err := models.WriteCollectionDir(dir, queries, envs)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s\nthe collection is written in %s", report, dir), nil
+
+	case "pull":
+		report, err := models.PullCollectionDir(dir, prune)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s\nthe saved queries and environments are updated from %s", report, dir), nil
+
+	default:
+		return fmt.Sprintf("Invalid action provided (%s). You must provide one of the actions below:\n%s\n", action, c.GetHelp()), nil
+	}
+}
@@ -21,6 +21,9 @@ func (c *VarCmd) GetCmds() []string {
 func (c *VarCmd) GetFlags() []ValidFlag {
 	return []ValidFlag{
 		{Key: "data", Labels: []string{"-d", "--data"}},
+		{Key: "name", Labels: []string{"-n", "--name"}},
+		{Key: "global", Labels: []string{"--global"}},
+		{Key: "secret", Labels: []string{"--secret"}},
 	}
 }
 
@@ -29,17 +32,20 @@ func (c *VarCmd) GetHelp() string {
 	return `
 gourl var list
 
-  List all the variables for the current environment
+  List all the variables for the current environment, including the ones inherited from its parents and the global variables, with the environment they come from.
 
-gourl var add --data key=value
+gourl var add --data key=value [--global true] [--secret true]
 
   Create a new variable in the current environment. If the key already exists, the value will be replaced.
 	--data, -d: The key/value of the variable, in the format: key=value
+	--global:   If true, the variable is shared by all the environments. The variables of an environment and of its parents take precedence.
+	--secret:   If true, the value is encrypted with the passphrase of the vault, and only decrypted when a query uses it. See gourl vault.
 
-gourl var remove --name <name>
+gourl var remove --name <name> [--global true]
 
   Delete a variable from the current environment.
-	--name, -n: The variable's key`
+	--name, -n: The variable's key
+	--global:   If true, the global variable is deleted.`
 }
 
 // create and send a new http request based on the provided parameters
@@ -56,18 +62,39 @@ func (c *VarCmd) Execute(cmd string, actions []string, flags []Flag) (string, er
 	action := actions[0]
 	switch action {
 	case "list":
+		variables, err := models.CurrentEnv.AllVariables()
+		if err != nil {
+			return "", err
+		}
 		allVars := ""
-		for key, value := range models.CurrentEnv.Variables {
-			allVars += fmt.Sprintf("%s: %s\n", key, value)
+		for _, variable := range variables {
+			if models.IsSecretValue(variable.Value) {
+				variable.Value = "******** (secret)"
+			}
+			switch variable.Origin {
+			case models.CurrentEnv.Name:
+				allVars += fmt.Sprintf("%s: %s\n", variable.Name, variable.Value)
+			case models.GlobalsEnvName:
+				allVars += fmt.Sprintf("%s: %s (global)\n", variable.Name, variable.Value)
+			default:
+				allVars += fmt.Sprintf("%s: %s (from %s)\n", variable.Name, variable.Value, variable.Origin)
+			}
 		}
 		return allVars, nil
 	case "add":
 		newKey := ""
 		newValue := ""
+		global := false
+		secret := false
 		for _, flag := range flags {
 			switch flag.Key {
+			case "global":
+				global = flag.Value == "true"
+			case "secret":
+				secret = flag.Value == "true"
 			case "data":
-				varParts := strings.Split(flag.Value, "=")
+				// the value can contain =, ex: a base64 token
+				varParts := strings.SplitN(flag.Value, "=", 2)
 				if len(varParts) != 2 {
 					return "", fmt.Errorf("wrong formatting %s. The --data flag must be --data key=value. Use `gourl var` to list all the options", flag.Value)
 				}
@@ -82,16 +109,35 @@ func (c *VarCmd) Execute(cmd string, actions []string, flags []Flag) (string, er
 			return "", fmt.Errorf("the --data flag is mandatory. Use `gourl var` to list all the options")
 		}
 
-		models.CurrentEnv.Variables[newKey] = newValue
-		db.Db.Save(&models.CurrentEnv)
+		if secret {
+			var err error
+			newValue, err = encryptSecret(newValue)
+			if err != nil {
+				return "", err
+			}
+		}
+
+		env := models.CurrentEnv
+		if global {
+			var err error
+			env, err = models.GetOrCreateGlobalEnv()
+			if err != nil {
+				return "", err
+			}
+		}
+		env.Variables[newKey] = newValue
+		db.Db.Save(env)
 
 		return fmt.Sprintln("done."), nil
 	case "remove":
 		nameToDelete := ""
+		global := false
 		for _, flag := range flags {
 			switch flag.Key {
 			case "name":
 				nameToDelete = flag.Value
+			case "global":
+				global = flag.Value == "true"
 			default:
 				return "", fmt.Errorf("the %s flag is unknown. Use `gourl var` to list all the options", flag.Key)
 
@@ -101,8 +147,15 @@ func (c *VarCmd) Execute(cmd string, actions []string, flags []Flag) (string, er
 			return "", fmt.Errorf("the --name flag is mandatory. Use `gourl var` to list all the options")
 		}
 
-		delete(models.CurrentEnv.Variables, nameToDelete)
-		db.Db.Save(&models.CurrentEnv)
+		env := models.CurrentEnv
+		if global {
+			if models.GlobalEnv == nil {
+				return "", fmt.Errorf("no global variable named %s exists", nameToDelete)
+			}
+			env = models.GlobalEnv
+		}
+		delete(env.Variables, nameToDelete)
+		db.Db.Save(env)
 		return "Done.", nil
 
 	default:
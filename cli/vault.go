@@ -0,0 +1,119 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/nakurai/gourl/models"
+)
+
+type VaultCmd struct{}
+
+// return all the commands that will lead to this execution path
+func (c *VaultCmd) GetCmds() []string {
+	return []string{
+		"vault",
+	}
+}
+
+// return all the flags this cmd can handle
+func (c *VaultCmd) GetFlags() []ValidFlag {
+	return []ValidFlag{}
+}
+
+// return all the flags this cmd can handle
+func (c *VaultCmd) GetHelp() string {
+	return `
+The secret variables (gourl var add --data key=value --secret true) are encrypted with a key derived from the passphrase of the vault. The passphrase is read from the GOURL_PASSPHRASE environment variable, from the file whose path is in GOURL_KEY_FILE, or asked when a query uses a secret.
+
+gourl vault status
+
+  Display the number of secret variables and if the vault is unlocked.
+
+gourl vault unlock
+
+  Check the passphrase and print the command setting GOURL_VAULT_KEY, so the secrets are decrypted without asking the passphrase again in this terminal. Use it as: eval $(gourl vault unlock)
+
+gourl vault lock
+
+  Print the command removing GOURL_VAULT_KEY. Use it as: eval $(gourl vault lock)
+
+gourl vault rotate
+
+  Encrypt all the secret variables again with a new key, derived from the same passphrase.
+
+gourl vault passphrase
+
+  Change the passphrase of the vault. All the secret variables are encrypted again with the new one.`
+}
+
+func (c *VaultCmd) Execute(cmd string, actions []string, flags []Flag) (string, error) {
+	if len(actions) == 0 {
+		return fmt.Sprintf("No action provided. You must provide one of the actions below:\n%s\n", c.GetHelp()), nil
+	}
+	if len(flags) > 0 {
+		return "", fmt.Errorf("the %s flag is unknown. Use `gourl vault` to list all the options", flags[0].Key)
+	}
+
+	action := actions[0]
+	switch action {
+	case "status":
+		count, err := models.CountSecrets()
+		if err != nil {
+			return "", err
+		}
+		res := fmt.Sprintf("%d secret variables", count)
+		if os.Getenv("GOURL_VAULT_KEY") != "" {
+			res += ", unlocked in this terminal by GOURL_VAULT_KEY"
+		}
+		return res, nil
+
+	case "unlock":
+		key, err := models.UnlockVault()
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("export GOURL_VAULT_KEY='%s'", key), nil
+
+	case "lock":
+		return "unset GOURL_VAULT_KEY", nil
+
+	case "rotate", "passphrase":
+		count, err := models.CountSecrets()
+		if err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return "", fmt.Errorf("there is no secret variable. Use `gourl var add --data key=value --secret true` to add one")
+		}
+		passphrase, err := readPassphrase(false)
+		if err != nil {
+			return "", err
+		}
+		// GOURL_VAULT_KEY would decrypt the secrets whatever the passphrase typed
+		err = models.CheckPassphrase(passphrase)
+		if err != nil {
+			return "", err
+		}
+		models.PassphraseProvider = func() (string, error) { return passphrase, nil }
+		newPassphrase := passphrase
+		if action == "passphrase" {
+			newPassphrase, err = askPassphrase("new passphrase", true)
+			if err != nil {
+				return "", err
+			}
+		}
+		count, err = models.RekeyVault(newPassphrase)
+		if err != nil {
+			return "", err
+		}
+		res := fmt.Sprintf("%d secret variables encrypted with a new key", count)
+		if os.Getenv("GOURL_VAULT_KEY") != "" {
+			res += ". GOURL_VAULT_KEY is not valid anymore, use `eval $(gourl vault unlock)` again"
+		}
+		return res, nil
+
+	default:
+		return fmt.Sprintf("Invalid action provided (%s). You must provide one of the actions below:\n%s\n", action, c.GetHelp()), nil
+	}
+}
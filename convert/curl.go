@@ -0,0 +1,478 @@
+package convert
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/nakurai/gourl/models"
+)
+
+var ansiEscapes = map[rune]rune{
+	'n': '\n', 't': '\t', 'r': '\r', '\\': '\\', '\'': '\'', '"': '"', '0': 0,
+}
+
+// split a command line into words following the shell quoting rules:
+// single quotes keep everything as is, double quotes and backslashes escape characters
+// and a backslash at the end of a line continues the command on the next one
+func SplitShellWords(command string) ([]string, error) {
+	words := []string{}
+	var current strings.Builder
+	inWord := false
+	runes := []rune(command)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '\\':
+			if i+1 >= len(runes) {
+				return nil, fmt.Errorf("the command ends with a lone backslash")
+			}
+			i += 1
+			// line continuation
+			if runes[i] == '\n' {
+				continue
+			}
+			if runes[i] == '\r' && i+1 < len(runes) && runes[i+1] == '\n' {
+				i += 1
+				continue
+			}
+			current.WriteRune(runes[i])
+			inWord = true
+		case r == '$' && i+1 < len(runes) && runes[i+1] == '\'':
+			// ansi-c quoting, ex: $'line\nnext line', used by the browsers' "copy as cURL"
+			i += 2
+			for ; i < len(runes) && runes[i] != '\''; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i += 1
+					escaped, ok := ansiEscapes[runes[i]]
+					if !ok {
+						current.WriteRune('\\')
+						escaped = runes[i]
+					}
+					current.WriteRune(escaped)
+					continue
+				}
+				current.WriteRune(runes[i])
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("unclosed single quote")
+			}
+			inWord = true
+		case r == '\'':
+			i += 1
+			for ; i < len(runes) && runes[i] != '\''; i++ {
+				current.WriteRune(runes[i])
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("unclosed single quote")
+			}
+			inWord = true
+		case r == '"':
+			i += 1
+			for ; i < len(runes) && runes[i] != '"'; i++ {
+				// inside double quotes, the backslash only escapes a few characters
+				if runes[i] == '\\' && i+1 < len(runes) && strings.ContainsRune("\"\\$`\n", runes[i+1]) {
+					i += 1
+					if runes[i] == '\n' {
+						continue
+					}
+				}
+				current.WriteRune(runes[i])
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("unclosed double quote")
+			}
+			inWord = true
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			if inWord {
+				words = append(words, current.String())
+				current.Reset()
+				inWord = false
+			}
+		default:
+			current.WriteRune(r)
+			inWord = true
+		}
+	}
+	if inWord {
+		words = append(words, current.String())
+	}
+	return words, nil
+}
+
+// curl options expecting a value that gourl does not support. Their value is skipped
+var unsupportedCurlValueOptions = map[string]bool{
+	"-o": true, "--output": true, "-m": true, "--max-time": true, "--connect-timeout": true,
+	"--retry": true, "-x": true, "--proxy": true, "--cacert": true, "--cert": true, "-E": true,
+	"--key": true, "-w": true, "--write-out": true, "-T": true, "--upload-file": true,
+	"--resolve": true, "-c": true, "--cookie-jar": true, "-r": true, "--range": true,
+	"--limit-rate": true, "-K": true, "--config": true, "--oauth2-bearer": true,
+}
+
+// curl options without value that do not change the request. They are ignored silently
+var ignoredCurlOptions = map[string]bool{
+	"-s": true, "--silent": true, "-S": true, "--show-error": true, "-v": true, "--verbose": true,
+	"-i": true, "--include": true, "-L": true, "--location": true, "--compressed": true,
+	"-g": true, "--globoff": true, "-#": true, "--progress-bar": true, "-f": true, "--fail": true,
+	"--no-progress-meter": true,
+}
+
+// short curl options expecting a value
+const curlShortValueOptions = "XHdbuFAeoxmwTErcK"
+
+// parse a curl command line into a query. It also returns the list of
+// the options that could not be converted
+func ParseCurl(command string) (*models.Query, []string, error) {
+	words, err := SplitShellWords(command)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(words) > 0 && words[0] == "curl" {
+		words = words[1:]
+	}
+
+	query := models.Query{
+		Data:   map[string]string{},
+		Header: map[string]string{},
+		Cookie: map[string]string{},
+	}
+	warnings := []string{}
+	method := ""
+	dataParts := []string{}
+	isGet := false
+	isJson := false
+	user := ""
+
+	args := words
+	for index := 0; index < len(args); index++ {
+		arg := args[index]
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			if query.Url != "" {
+				warnings = append(warnings, fmt.Sprintf("only one url is supported, %s is ignored", arg))
+				continue
+			}
+			query.Url = arg
+			continue
+		}
+
+		// expand the grouped short options, ex: -sSL => -s -S -L, -XPOST => -X POST
+		if len(arg) > 2 && arg[1] != '-' {
+			expanded := []string{}
+			for runeIndex, r := range arg[1:] {
+				expanded = append(expanded, "-"+string(r))
+				if strings.ContainsRune(curlShortValueOptions, r) {
+					if runeIndex+2 < len(arg) {
+						expanded = append(expanded, arg[runeIndex+2:])
+					}
+					break
+				}
+			}
+			args = append(args[:index], append(expanded, args[index+1:]...)...)
+			arg = args[index]
+		}
+
+		// --option=value is the same as --option value
+		option, inlineValue, hasInlineValue := strings.Cut(arg, "=")
+		if !strings.HasPrefix(arg, "--") {
+			option, inlineValue, hasInlineValue = arg, "", false
+		}
+		nextValue := func() (string, error) {
+			if hasInlineValue {
+				return inlineValue, nil
+			}
+			if index+1 >= len(args) {
+				return "", fmt.Errorf("the option %s has no value", option)
+			}
+			index += 1
+			return args[index], nil
+		}
+
+		switch option {
+		case "--url":
+			value, err := nextValue()
+			if err != nil {
+				return nil, nil, err
+			}
+			query.Url = value
+		case "-X", "--request":
+			value, err := nextValue()
+			if err != nil {
+				return nil, nil, err
+			}
+			method = strings.ToUpper(value)
+		case "-I", "--head":
+			method = "HEAD"
+		case "-G", "--get":
+			isGet = true
+		case "-H", "--header":
+			value, err := nextValue()
+			if err != nil {
+				return nil, nil, err
+			}
+			name, headerValue, ok := strings.Cut(value, ":")
+			if !ok {
+				warnings = append(warnings, fmt.Sprintf("the header %s is ill formatted and is ignored", value))
+				continue
+			}
+			name = strings.ToLower(strings.TrimSpace(name))
+			headerValue = strings.TrimSpace(headerValue)
+			if name == "cookie" {
+				parseCookies(headerValue, query.Cookie)
+				continue
+			}
+			if _, exists := query.Header[name]; exists {
+				warnings = append(warnings, fmt.Sprintf("the header %s is set several times, only the last value is kept", name))
+			}
+			query.Header[name] = headerValue
+		case "-A", "--user-agent":
+			value, err := nextValue()
+			if err != nil {
+				return nil, nil, err
+			}
+			query.Header["user-agent"] = value
+		case "-e", "--referer":
+			value, err := nextValue()
+			if err != nil {
+				return nil, nil, err
+			}
+			query.Header["referer"] = value
+		case "-u", "--user":
+			value, err := nextValue()
+			if err != nil {
+				return nil, nil, err
+			}
+			user = value
+		case "-b", "--cookie":
+			value, err := nextValue()
+			if err != nil {
+				return nil, nil, err
+			}
+			if !strings.Contains(value, "=") {
+				warnings = append(warnings, fmt.Sprintf("reading cookies from a file (%s) is not supported", value))
+				continue
+			}
+			parseCookies(value, query.Cookie)
+		case "-d", "--data", "--data-ascii", "--data-binary", "--data-raw", "--json":
+			value, err := nextValue()
+			if err != nil {
+				return nil, nil, err
+			}
+			if option == "--json" {
+				isJson = true
+			}
+			if strings.HasPrefix(value, "@") && option != "--data-raw" {
+				content, err := readCurlFile(strings.TrimPrefix(value, "@"))
+				if err != nil {
+					warnings = append(warnings, err.Error())
+					continue
+				}
+				value = content
+				if option != "--data-binary" && option != "--json" {
+					// like curl, -d removes the line breaks of the files
+					value = strings.NewReplacer("\r", "", "\n", "").Replace(value)
+				}
+			}
+			dataParts = append(dataParts, value)
+		case "--data-urlencode":
+			value, err := nextValue()
+			if err != nil {
+				return nil, nil, err
+			}
+			part, err := curlUrlEncode(value)
+			if err != nil {
+				warnings = append(warnings, err.Error())
+				continue
+			}
+			dataParts = append(dataParts, part)
+		case "-F", "--form", "--form-string":
+			value, err := nextValue()
+			if err != nil {
+				return nil, nil, err
+			}
+			name, formValue, ok := strings.Cut(value, "=")
+			if !ok {
+				warnings = append(warnings, fmt.Sprintf("the form field %s is ill formatted and is ignored", value))
+				continue
+			}
+			switch {
+			case option == "--form-string":
+				// the value is a text, even if it starts with @
+				formValue = models.EscapeMultipartText(formValue)
+			case strings.HasPrefix(formValue, "<"):
+				warnings = append(warnings, fmt.Sprintf("the form field %s is not supported", value))
+				continue
+			case strings.HasPrefix(formValue, "@") && strings.Contains(formValue, ";"):
+				// ex: file=@photo.png;type=image/png, the content type of the part is not supported
+				warnings = append(warnings, fmt.Sprintf("the options of the form field %s are ignored", value))
+				formValue, _, _ = strings.Cut(formValue, ";")
+			}
+			if _, exists := query.Data[name]; exists {
+				warnings = append(warnings, fmt.Sprintf("the form field %s is set several times, only the last value is kept", name))
+			}
+			query.IsMultipart = true
+			query.Data[name] = formValue
+		case "-k", "--insecure":
+			warnings = append(warnings, fmt.Sprintf("the option %s is not supported, the certificates will be verified", option))
+		default:
+			if ignoredCurlOptions[option] {
+				continue
+			}
+			if unsupportedCurlValueOptions[option] {
+				_, err := nextValue()
+				if err != nil {
+					return nil, nil, err
+				}
+				warnings = append(warnings, fmt.Sprintf("the option %s is not supported and is ignored", option))
+				continue
+			}
+			warnings = append(warnings, fmt.Sprintf("the option %s is unknown and is ignored", option))
+		}
+	}
+
+	if query.Url == "" {
+		return nil, nil, fmt.Errorf("no url found in the curl command")
+	}
+	// like curl, an explicit authorization header wins over -u
+	if user != "" {
+		if _, exists := query.Header["authorization"]; exists {
+			warnings = append(warnings, "the credentials of -u are ignored, the authorization header is used")
+		} else {
+			query.Header["authorization"] = "Basic " + base64.StdEncoding.EncodeToString([]byte(user))
+		}
+	}
+	// like curl, urls without scheme use http
+	if !strings.Contains(query.Url, "://") {
+		query.Url = "http://" + query.Url
+	}
+
+	switch {
+	case method != "":
+		query.Method = method
+	case isGet:
+		query.Method = "GET"
+	case len(dataParts) > 0 || query.IsMultipart:
+		query.Method = "POST"
+	default:
+		query.Method = "GET"
+	}
+	if isGet && query.IsMultipart {
+		warnings = append(warnings, "form fields cannot be sent with --get")
+	}
+
+	if len(dataParts) > 0 {
+		data := strings.Join(dataParts, "&")
+		values, isForm := parseForm(data)
+		if isGet {
+			// the data is sent in the url's query string
+			if !isForm || query.HasBody() {
+				separator := "?"
+				if strings.Contains(query.Url, "?") {
+					separator = "&"
+				}
+				query.Url += separator + data
+			} else {
+				for key, value := range values {
+					query.Data[key] = value
+				}
+			}
+		} else {
+			if isJson {
+				setDefaultHeader(query.Header, "content-type", "application/json")
+				setDefaultHeader(query.Header, "accept", "application/json")
+			}
+			setDefaultHeader(query.Header, "content-type", "application/x-www-form-urlencoded")
+			// form encoded data are easier to read and edit as key/value pairs,
+			// as long as they will be sent in the body
+			isFormBody := query.Header["content-type"] == "application/x-www-form-urlencoded" && query.HasBody()
+			if isForm && isFormBody && !query.IsMultipart {
+				for key, value := range values {
+					query.Data[key] = value
+				}
+			} else {
+				query.Body = data
+			}
+		}
+	}
+
+	// the values of the command are literals, they must not be expanded when the query is sent
+	escapeQueryVariables(&query)
+	return &query, warnings, nil
+}
+
+func readCurlFile(filePath string) (string, error) {
+	if filePath == "-" {
+		return "", fmt.Errorf("reading the data from stdin is not supported")
+	}
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return "", fmt.Errorf("while reading the data file %s: %v", filePath, err)
+	}
+	return string(content), nil
+}
+
+// convert a --data-urlencode value into an url encoded string, following curl's rules:
+// content, =content, name=content, @file or name@file
+func curlUrlEncode(value string) (string, error) {
+	equalIndex := strings.Index(value, "=")
+	atIndex := strings.Index(value, "@")
+	switch {
+	case equalIndex == 0:
+		return url.QueryEscape(value[1:]), nil
+	case equalIndex > 0 && (atIndex == -1 || equalIndex < atIndex):
+		return value[:equalIndex] + "=" + url.QueryEscape(value[equalIndex+1:]), nil
+	case atIndex >= 0:
+		content, err := readCurlFile(value[atIndex+1:])
+		if err != nil {
+			return "", err
+		}
+		if atIndex == 0 {
+			return url.QueryEscape(content), nil
+		}
+		return value[:atIndex] + "=" + url.QueryEscape(content), nil
+	default:
+		return url.QueryEscape(value), nil
+	}
+}
+
+// parse form encoded data. It returns false if the data cannot be
+// represented as a list of unique keys with a value
+func parseForm(data string) (map[string]string, bool) {
+	res := map[string]string{}
+	for _, pair := range strings.Split(data, "&") {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || key == "" {
+			return nil, false
+		}
+		decodedKey, err := url.QueryUnescape(key)
+		if err != nil {
+			return nil, false
+		}
+		decodedValue, err := url.QueryUnescape(value)
+		if err != nil {
+			return nil, false
+		}
+		if _, exists := res[decodedKey]; exists {
+			return nil, false
+		}
+		res[decodedKey] = decodedValue
+	}
+	return res, true
+}
+
+// parse cookies formatted as in the Cookie header: a=b; c=d
+func parseCookies(value string, cookies map[string]string) {
+	for _, cookie := range strings.Split(value, ";") {
+		name, cookieValue, ok := strings.Cut(strings.TrimSpace(cookie), "=")
+		if ok && name != "" {
+			cookies[name] = cookieValue
+		}
+	}
+}
+
+func setDefaultHeader(header map[string]string, key string, value string) {
+	if _, ok := header[key]; !ok {
+		header[key] = value
+	}
+}
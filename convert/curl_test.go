@@ -0,0 +1,89 @@
+package convert
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSplitShellWords(t *testing.T) {
+	words, err := SplitShellWords(`curl 'https://example.com/a b' -H "X-Test: \"quoted\"" \
+  --data-raw $'{"a":"line\nnext"}' plain\ word`)
+	if err != nil {
+		t.Errorf("%v\n", err)
+		return
+	}
+	expected := []string{"curl", "https://example.com/a b", "-H", `X-Test: "quoted"`, "--data-raw", "{\"a\":\"line\nnext\"}", "plain word"}
+	if strings.Join(words, "|") != strings.Join(expected, "|") {
+		t.Errorf("words should be %q not %q\n", expected, words)
+	}
+
+	_, err = SplitShellWords(`curl 'https://example.com`)
+	if err == nil {
+		t.Errorf("an unclosed quote should return an error")
+	}
+}
+
+func TestParseCurl(t *testing.T) {
+	query, warnings, err := ParseCurl(`curl -sSL -XPUT https://example.com/api/users/1 -H 'Authorization: Bearer xyz' -H 'Cookie: a=1; b=2' -u john:secret --json '{"name":"john"}' -k`)
+	if err != nil {
+		t.Errorf("%v\n", err)
+		return
+	}
+	if query.Method != "PUT" || query.Url != "https://example.com/api/users/1" {
+		t.Errorf("unexpected method or url: %s %s\n", query.Method, query.Url)
+	}
+	if query.Header["authorization"] != "Bearer xyz" {
+		t.Errorf("the authorization header should win over -u, not %s\n", query.Header["authorization"])
+	}
+	if query.Cookie["a"] != "1" || query.Cookie["b"] != "2" {
+		t.Errorf("the cookie header should be converted to cookies, not %v\n", query.Cookie)
+	}
+	if query.Body != `{"name":"john"}` || query.Header["content-type"] != "application/json" {
+		t.Errorf("--json should set a raw JSON body, not %s (%s)\n", query.Body, query.Header["content-type"])
+	}
+	if len(warnings) != 2 || !strings.Contains(warnings[0], "-k") || !strings.Contains(warnings[1], "-u") {
+		t.Errorf("-k should be reported as unsupported and -u as ignored, warnings: %v\n", warnings)
+	}
+
+	query, warnings, err = ParseCurl(`curl https://example.com -u john:secret -H 'X-Id: 1' -H 'x-id: 2' -F a=1 -F a=2`)
+	if err != nil {
+		t.Errorf("%v\n", err)
+		return
+	}
+	if query.Header["authorization"] != "Basic am9objpzZWNyZXQ=" {
+		t.Errorf("-u should set the authorization header, not %s\n", query.Header["authorization"])
+	}
+	if query.Header["x-id"] != "2" || query.Data["a"] != "2" || len(warnings) != 2 {
+		t.Errorf("the repeated headers and form fields should keep the last value with a warning, not %v %v %v\n", query.Header, query.Data, warnings)
+	}
+
+	query, _, err = ParseCurl(`curl example.com/search -d q=gourl --data-urlencode 'text=a b&c'`)
+	if err != nil {
+		t.Errorf("%v\n", err)
+		return
+	}
+	if query.Method != "POST" || query.Url != "http://example.com/search" {
+		t.Errorf("unexpected method or url: %s %s\n", query.Method, query.Url)
+	}
+	if query.Data["q"] != "gourl" || query.Data["text"] != "a b&c" || query.Body != "" {
+		t.Errorf("form encoded data should be converted to key/value pairs, not %v\n", query.Data)
+	}
+
+	query, _, err = ParseCurl(`curl -G https://example.com/search -d q=gourl`)
+	if err != nil {
+		t.Errorf("%v\n", err)
+		return
+	}
+	if query.Method != "GET" || query.Data["q"] != "gourl" {
+		t.Errorf("-G should send the data in the query string, not %s %v\n", query.Method, query.Data)
+	}
+
+	query, _, err = ParseCurl(`curl https://example.com/upload -F name=avatar -F file=@./me.png`)
+	if err != nil {
+		t.Errorf("%v\n", err)
+		return
+	}
+	if !query.IsMultipart || query.Method != "POST" || query.Data["file"] != "@./me.png" {
+		t.Errorf("-F should create a multipart form, not %v %s %v\n", query.IsMultipart, query.Method, query.Data)
+	}
+}
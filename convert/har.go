@@ -0,0 +1,388 @@
+package convert
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/nakurai/gourl/models"
+)
+
+// the parts of the HAR 1.2 format gourl reads and writes
+type harDocument struct {
+	Log harLog `json:"log"`
+}
+
+type harLog struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Entries []harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	Url         string         `json:"url"`
+	HttpVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HttpVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harNameValue struct {
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	FileName string `json:"fileName,omitempty"`
+}
+
+type harPostData struct {
+	MimeType string         `json:"mimeType"`
+	Text     string         `json:"text,omitempty"`
+	Params   []harNameValue `json:"params,omitempty"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// a request read from a HAR file, with the response received at the time
+type HarImport struct {
+	Query    models.Query
+	Response *models.Response
+}
+
+// a request sent (or about to be sent) and its response, to be written in a HAR file.
+// The response is nil if the request was never sent
+type HarExchange struct {
+	Request     *http.Request
+	RequestBody []byte
+	Response    *models.Response
+}
+
+// the headers handled by the http client itself, they are not imported
+var harSkippedHeaders = map[string]bool{
+	"accept-encoding":   true,
+	"connection":        true,
+	"content-length":    true,
+	"cookie":            true,
+	"host":              true,
+	"transfer-encoding": true,
+}
+
+// convert the entries of a HAR file into queries named <prefix>/<host>/<path>. Only the
+// entries whose url matches the pattern (a regular expression) and whose method is one
+// of the methods are kept, if provided. It also returns the list of what could not be converted
+func ParseHar(content []byte, prefix string, urlPattern string, methods []string) ([]HarImport, []string, error) {
+	var document harDocument
+	err := json.Unmarshal(content, &document)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid HAR file: %v", err)
+	}
+	var urlRegex *regexp.Regexp
+	if urlPattern != "" {
+		urlRegex, err = regexp.Compile(urlPattern)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid url pattern %s: %v", urlPattern, err)
+		}
+	}
+	for index := range methods {
+		methods[index] = strings.ToUpper(methods[index])
+	}
+
+	warnings := []string{}
+	imports := []HarImport{}
+	usedNames := map[string]bool{}
+	for _, entry := range document.Log.Entries {
+		request := entry.Request
+		if urlRegex != nil && !urlRegex.MatchString(request.Url) {
+			continue
+		}
+		if len(methods) > 0 && !slices.Contains(methods, strings.ToUpper(request.Method)) {
+			continue
+		}
+		requestUrl, err := url.Parse(request.Url)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("the url %s is invalid, the entry is skipped", request.Url))
+			continue
+		}
+
+		name := harQueryName(prefix, requestUrl)
+		uniqueName := name
+		for index := 2; usedNames[uniqueName]; index++ {
+			uniqueName = fmt.Sprintf("%s (%d)", name, index)
+		}
+		usedNames[uniqueName] = true
+
+		query := models.Query{
+			Name:   uniqueName,
+			Method: strings.ToUpper(request.Method),
+			Url:    request.Url,
+			Data:   map[string]string{},
+			Header: map[string]string{},
+			Cookie: map[string]string{},
+		}
+		for _, header := range request.Headers {
+			headerName := strings.ToLower(header.Name)
+			// the http/2 pseudo headers, ex: :authority
+			if strings.HasPrefix(headerName, ":") || harSkippedHeaders[headerName] {
+				continue
+			}
+			query.Header[headerName] = header.Value
+		}
+		for _, cookie := range request.Cookies {
+			query.Cookie[cookie.Name] = cookie.Value
+		}
+
+		// the recorded values are literals, they must not be expanded when the query is sent
+		escapeQueryVariables(&query)
+
+		if postData := request.PostData; postData != nil {
+			mimeType, _, _ := mime.ParseMediaType(postData.MimeType)
+			switch {
+			case mimeType == "multipart/form-data" && len(postData.Params) > 0:
+				query.IsMultipart = true
+				// the boundary of the parts is generated when the query is sent
+				delete(query.Header, "content-type")
+				for _, param := range postData.Params {
+					if param.FileName != "" {
+						query.Data[param.Name] = "@" + escapeVariables(param.FileName)
+						warnings = append(warnings, fmt.Sprintf("the file %s of %s must be found at %s when the query is sent", param.Name, uniqueName, param.FileName))
+						continue
+					}
+					// a text starting with @ is not the path of a file to upload
+					query.Data[param.Name] = models.EscapeMultipartText(escapeVariables(param.Value))
+				}
+			case mimeType == "application/x-www-form-urlencoded" && len(postData.Params) > 0:
+				for _, param := range postData.Params {
+					name, _ := url.QueryUnescape(param.Name)
+					value, _ := url.QueryUnescape(param.Value)
+					query.Data[name] = escapeVariables(value)
+				}
+			default:
+				query.Body = escapeVariables(postData.Text)
+			}
+			// the data can only be sent in the body of these methods
+			if len(query.Data) > 0 && !query.HasBody() {
+				query.Body = escapeVariables(postData.Text)
+				query.Data = map[string]string{}
+				query.IsMultipart = false
+			}
+		}
+
+		response, err := harResponseOf(entry)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("the response of %s is ignored: %v", uniqueName, err))
+		}
+		imports = append(imports, HarImport{Query: query, Response: response})
+	}
+	return imports, warnings, nil
+}
+
+// return the name of the query: the host and the path of its url, under the prefix
+func harQueryName(prefix string, requestUrl *url.URL) string {
+	parts := []string{}
+	if prefix = strings.Trim(prefix, "/"); prefix != "" {
+		parts = append(parts, prefix)
+	}
+	parts = append(parts, sanitizeQueryName(requestUrl.Host))
+	for _, part := range strings.Split(strings.Trim(path.Clean(requestUrl.Path), "/"), "/") {
+		if part != "" && part != "." {
+			parts = append(parts, sanitizeQueryName(part))
+		}
+	}
+	if len(parts) == 1 || (prefix != "" && len(parts) == 2) {
+		parts = append(parts, "index")
+	}
+	return strings.Join(parts, "/")
+}
+
+// return the response recorded in the entry, nil if the request was never answered
+func harResponseOf(entry harEntry) (*models.Response, error) {
+	if entry.Response.Status == 0 {
+		return nil, nil
+	}
+	body := entry.Response.Content.Text
+	if entry.Response.Content.Encoding == "base64" {
+		decoded, err := base64.StdEncoding.DecodeString(body)
+		if err != nil {
+			return nil, fmt.Errorf("invalid base64 content: %v", err)
+		}
+		body = string(decoded)
+	}
+	header := http.Header{}
+	for _, h := range entry.Response.Headers {
+		header.Add(h.Name, h.Value)
+	}
+	startedAt, _ := time.Parse(time.RFC3339Nano, entry.StartedDateTime)
+	return &models.Response{
+		Url:        entry.Request.Url,
+		Proto:      entry.Response.HttpVersion,
+		Status:     strings.TrimSpace(fmt.Sprintf("%d %s", entry.Response.Status, entry.Response.StatusText)),
+		StatusCode: entry.Response.Status,
+		Header:     header,
+		Body:       body,
+		StartedAt:  startedAt,
+		Duration:   time.Duration(entry.Time * float64(time.Millisecond)),
+	}, nil
+}
+
+// write the requests and their responses as a HAR 1.2 document
+func ExportHar(exchanges []HarExchange) (string, error) {
+	document := harDocument{Log: harLog{
+		Version: "1.2",
+		Creator: harCreator{Name: "gourl", Version: "1.0"},
+		Entries: []harEntry{},
+	}}
+	for _, exchange := range exchanges {
+		req := exchange.Request
+		entry := harEntry{
+			StartedDateTime: time.Now().UTC().Format(time.RFC3339Nano),
+			Request: harRequest{
+				Method:      req.Method,
+				Url:         req.URL.String(),
+				HttpVersion: "HTTP/1.1",
+				Cookies:     []harNameValue{},
+				Headers:     harHeaders(req.Header),
+				QueryString: []harNameValue{},
+				HeadersSize: -1,
+				BodySize:    len(exchange.RequestBody),
+			},
+		}
+		for _, cookie := range req.Cookies() {
+			entry.Request.Cookies = append(entry.Request.Cookies, harNameValue{Name: cookie.Name, Value: cookie.Value})
+		}
+		urlQuery := req.URL.Query()
+		for _, key := range slices.Sorted(maps.Keys(urlQuery)) {
+			for _, value := range urlQuery[key] {
+				entry.Request.QueryString = append(entry.Request.QueryString, harNameValue{Name: key, Value: value})
+			}
+		}
+		if len(exchange.RequestBody) > 0 {
+			entry.Request.PostData = &harPostData{
+				MimeType: req.Header.Get("Content-Type"),
+				Text:     string(exchange.RequestBody),
+			}
+		}
+
+		res := exchange.Response
+		if res == nil {
+			entry.Response = harResponse{
+				Cookies:     []harNameValue{},
+				Headers:     []harNameValue{},
+				Content:     harContent{MimeType: "x-unknown"},
+				HeadersSize: -1,
+				BodySize:    -1,
+			}
+		} else {
+			if !res.StartedAt.IsZero() {
+				entry.StartedDateTime = res.StartedAt.UTC().Format(time.RFC3339Nano)
+			}
+			// only the total duration is known, it is reported as the waiting time
+			entry.Time = float64(res.Duration) / float64(time.Millisecond)
+			entry.Timings.Wait = entry.Time
+			proto := res.Proto
+			if proto == "" {
+				proto = "HTTP/1.1"
+			}
+			content := harContent{Size: len(res.Body), MimeType: res.Header.Get("Content-Type"), Text: res.Body}
+			if !utf8.ValidString(res.Body) {
+				content.Text = base64.StdEncoding.EncodeToString([]byte(res.Body))
+				content.Encoding = "base64"
+			}
+			entry.Response = harResponse{
+				Status:      res.StatusCode,
+				StatusText:  strings.TrimSpace(strings.TrimPrefix(res.Status, fmt.Sprint(res.StatusCode))),
+				HttpVersion: proto,
+				Cookies:     []harNameValue{},
+				Headers:     harHeaders(res.Header),
+				Content:     content,
+				RedirectURL: res.Header.Get("Location"),
+				HeadersSize: -1,
+				BodySize:    len(res.Body),
+			}
+			for _, cookie := range (&http.Response{Header: res.Header}).Cookies() {
+				entry.Response.Cookies = append(entry.Response.Cookies, harNameValue{Name: cookie.Name, Value: cookie.Value})
+			}
+		}
+		document.Log.Entries = append(document.Log.Entries, entry)
+	}
+
+	res, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("while encoding the HAR document: %v", err)
+	}
+	return string(res) + "\n", nil
+}
+
+func harHeaders(header http.Header) []harNameValue {
+	headers := []harNameValue{}
+	for _, key := range slices.Sorted(maps.Keys(header)) {
+		for _, value := range header[key] {
+			headers = append(headers, harNameValue{Name: key, Value: value})
+		}
+	}
+	return headers
+}
+
+// read the body of the request and put it back, so the request can still be sent
+func ReadRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil {
+		return nil, nil
+	}
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, fmt.Errorf("while reading the body of the request: %v", err)
+	}
+	req.Body.Close()
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
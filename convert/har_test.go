@@ -0,0 +1,93 @@
+package convert
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nakurai/gourl/models"
+)
+
+const harSample = `{"log": {"version": "1.2", "creator": {"name": "browser", "version": "1"}, "entries": [
+	{"startedDateTime": "2024-05-01T10:00:00.000Z", "time": 42.5,
+		"request": {"method": "POST", "url": "https://api.example.com/v1/login?next=home", "httpVersion": "HTTP/2",
+			"headers": [{"name": ":authority", "value": "api.example.com"}, {"name": "Accept-Encoding", "value": "gzip"}, {"name": "X-Client", "value": "web"}],
+			"cookies": [{"name": "session", "value": "abc"}],
+			"postData": {"mimeType": "application/x-www-form-urlencoded", "text": "user=john", "params": [{"name": "user", "value": "john"}]}},
+		"response": {"status": 200, "statusText": "OK", "httpVersion": "HTTP/2", "headers": [{"name": "Content-Type", "value": "application/json"}],
+			"content": {"size": 11, "mimeType": "application/json", "text": "eyJvayI6IDF9", "encoding": "base64"}}},
+	{"startedDateTime": "2024-05-01T10:00:01.000Z", "time": 10,
+		"request": {"method": "GET", "url": "https://cdn.example.com/logo.png", "headers": []},
+		"response": {"status": 200, "statusText": "OK", "headers": [], "content": {"size": 0, "mimeType": "image/png"}}}
+]}}`
+
+func TestParseHar(t *testing.T) {
+	imports, warnings, err := ParseHar([]byte(harSample), "rec", `api\.example\.com`, []string{"post"})
+	if err != nil || len(warnings) > 0 {
+		t.Errorf("%v %v\n", err, warnings)
+		return
+	}
+	if len(imports) != 1 {
+		t.Errorf("only the entries matching the filters should be imported, not %d\n", len(imports))
+		return
+	}
+	query := imports[0].Query
+	if query.Name != "rec/api.example.com/v1/login" || query.Url != "https://api.example.com/v1/login?next=home" {
+		t.Errorf("unexpected query: %s %s\n", query.Name, query.Url)
+	}
+	if len(query.Header) != 1 || query.Header["x-client"] != "web" || query.Cookie["session"] != "abc" || query.Data["user"] != "john" {
+		t.Errorf("unexpected headers, cookies or data: %v %v %v\n", query.Header, query.Cookie, query.Data)
+	}
+	res := imports[0].Response
+	if res == nil || res.Body != `{"ok": 1}` || res.StatusCode != 200 || res.Duration != 42500*time.Microsecond {
+		t.Errorf("the recorded response should be decoded: %+v\n", res)
+	}
+}
+
+func TestExportHar(t *testing.T) {
+	req, _ := http.NewRequest("POST", "https://api.example.com/users?page=2", strings.NewReader(`{"name":"john"}`))
+	req.Header.Set("Content-Type", "application/json")
+	body, err := ReadRequestBody(req)
+	if err != nil || string(body) != `{"name":"john"}` {
+		t.Errorf("the body should be read: %s %v\n", body, err)
+		return
+	}
+	res := &models.Response{Status: "201 Created", StatusCode: 201, Header: http.Header{"Content-Type": {"application/json"}}, Body: `{"id":1}`, Duration: 15 * time.Millisecond}
+	content, err := ExportHar([]HarExchange{{Request: req, RequestBody: body, Response: res}})
+	if err != nil {
+		t.Errorf("%v\n", err)
+		return
+	}
+
+	imports, _, err := ParseHar([]byte(content), "", "", nil)
+	if err != nil || len(imports) != 1 {
+		t.Errorf("the export should be importable: %v\n", err)
+		return
+	}
+	query := imports[0].Query
+	if query.Name != "api.example.com/users" || query.Body != `{"name":"john"}` || query.Header["content-type"] != "application/json" {
+		t.Errorf("unexpected query: %s %s %v\n", query.Name, query.Body, query.Header)
+	}
+	if imported := imports[0].Response; imported.Status != "201 Created" || imported.Body != `{"id":1}` || imported.Duration != 15*time.Millisecond {
+		t.Errorf("unexpected response: %+v\n", imported)
+	}
+}
+
+func TestParseHarMultipart(t *testing.T) {
+	imports, _, err := ParseHar([]byte(`{"log": {"entries": [{"startedDateTime": "2024-05-01T10:00:00.000Z",
+		"request": {"method": "POST", "url": "https://example.com/upload", "headers": [],
+			"postData": {"mimeType": "multipart/form-data; boundary=x", "params": [
+				{"name": "handle", "value": "@john"},
+				{"name": "avatar", "fileName": "%{file:me}%.png"}
+			]}},
+		"response": {"status": 204, "statusText": "No Content", "headers": [], "content": {"size": 0}}}]}}`), "", "", nil)
+	if err != nil || len(imports) != 1 {
+		t.Errorf("%v\n", err)
+		return
+	}
+	query := imports[0].Query
+	if query.Data["handle"] != "@@john" || query.Data["avatar"] != "@%%{file:me}%.png" {
+		t.Errorf("the recorded texts and file names should be escaped, not %v\n", query.Data)
+	}
+}
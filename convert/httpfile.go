@@ -0,0 +1,296 @@
+package convert
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/nakurai/gourl/models"
+)
+
+var httpFileVarRegex = regexp.MustCompile(`^@([\w-]+)\s*=\s*(.*)$`)
+var httpFileNameRegex = regexp.MustCompile(`^(?:#|//)\s*@name\s+(\S+)`)
+var httpRequestLineRegex = regexp.MustCompile(`^([A-Z]+)\s+(\S+)(?:\s+HTTP/[\d.]+)?$`)
+var httpHeaderRegex = regexp.MustCompile(`^([\w-]+)\s*:\s*(.*)$`)
+
+// the methods a request line of a .http file can start with
+var httpFileMethods = []string{"GET", "POST", "PUT", "DELETE", "PATCH", "HEAD", "OPTIONS", "CONNECT", "TRACE"}
+
+// parse a .http (or .rest) file, as written for the VS Code REST Client or the JetBrains
+// http client. Requests are separated by ###, and named by their # @name annotation or
+// the text following the ###. The file variables (@var = value) are replaced by their
+// value, and the other {{var}} variables become %{var}% gourl variables, resolved in the
+// current environment. Files included in bodies (< ./file) are read from the directory.
+// It also returns the list of what could not be converted
+func ParseHttpFile(content string, dir string) ([]models.Query, []string, error) {
+	warnings := []string{}
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+
+	// the file variables can be used by every request of the file, wherever they are defined.
+	// They are written before the request line of a block, the lines after it belong to the request
+	fileVariables := map[string]string{}
+	inRequest := false
+	for _, line := range lines {
+		trimmedLine := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "###"):
+			inRequest = false
+		case inRequest:
+		case httpFileVarRegex.MatchString(trimmedLine):
+			match := httpFileVarRegex.FindStringSubmatch(trimmedLine)
+			fileVariables[match[1]] = strings.TrimSpace(match[2])
+		case trimmedLine != "" && !strings.HasPrefix(trimmedLine, "#") && !strings.HasPrefix(trimmedLine, "//"):
+			inRequest = true
+		}
+	}
+	resolve := func(s string) string {
+		// a file variable can use another one, within reason
+		for depth := 0; depth < 10; depth++ {
+			replaced := braceVarRegex.ReplaceAllStringFunc(s, func(match string) string {
+				if value, ok := fileVariables[braceVarRegex.FindStringSubmatch(match)[1]]; ok {
+					return value
+				}
+				return match
+			})
+			if replaced == s {
+				break
+			}
+			s = replaced
+		}
+		for _, match := range braceVarRegex.FindAllStringSubmatch(s, -1) {
+			if strings.Contains(match[1], ".response.") || strings.Contains(match[1], ".request.") {
+				warnings = append(warnings, fmt.Sprintf("the request variable %s is not supported", match[0]))
+			}
+		}
+		return convertBraceVariables(s, &warnings)
+	}
+
+	queries := []models.Query{}
+	usedNames := map[string]bool{}
+	blockTitle := ""
+	block := []string{}
+	flush := func() error {
+		query, err := parseHttpBlock(block, blockTitle, dir, resolve)
+		if err != nil || query == nil {
+			return err
+		}
+		if query.Name == "" {
+			query.Name = fmt.Sprintf("request %d", len(queries)+1)
+		}
+		uniqueName := query.Name
+		for index := 2; usedNames[uniqueName]; index++ {
+			uniqueName = fmt.Sprintf("%s (%d)", query.Name, index)
+		}
+		usedNames[uniqueName] = true
+		query.Name = uniqueName
+		queries = append(queries, *query)
+		return nil
+	}
+	for _, line := range lines {
+		if strings.HasPrefix(line, "###") {
+			err := flush()
+			if err != nil {
+				return nil, nil, err
+			}
+			blockTitle = strings.TrimSpace(strings.TrimLeft(line, "#"))
+			block = []string{}
+			continue
+		}
+		block = append(block, line)
+	}
+	err := flush()
+	if err != nil {
+		return nil, nil, err
+	}
+	return queries, warnings, nil
+}
+
+// parse the lines of one request. It returns nil if the block has no request
+func parseHttpBlock(lines []string, title string, dir string, resolve func(string) string) (*models.Query, error) {
+	query := models.Query{
+		Name:   title,
+		Data:   map[string]string{},
+		Header: map[string]string{},
+		Cookie: map[string]string{},
+	}
+
+	// the comments and variables before the request line
+	index := 0
+	for ; index < len(lines); index++ {
+		line := strings.TrimSpace(lines[index])
+		if match := httpFileNameRegex.FindStringSubmatch(line); match != nil {
+			query.Name = match[1]
+			continue
+		}
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "//") || httpFileVarRegex.MatchString(line) {
+			continue
+		}
+		break
+	}
+	if index == len(lines) {
+		return nil, nil
+	}
+
+	requestLine := strings.TrimSpace(lines[index])
+	if match := httpRequestLineRegex.FindStringSubmatch(requestLine); match != nil && slices.Contains(httpFileMethods, match[1]) {
+		query.Method = match[1]
+		query.Url = match[2]
+	} else {
+		// without method, the request is a GET
+		query.Method = "GET"
+		query.Url = strings.Fields(requestLine)[0]
+	}
+	index++
+
+	// the query string can continue on the following lines, ex: ?page=2 or &limit=10
+	for ; index < len(lines); index++ {
+		line := strings.TrimSpace(lines[index])
+		if !strings.HasPrefix(line, "?") && !strings.HasPrefix(line, "&") {
+			break
+		}
+		query.Url += line
+	}
+	query.Url = resolve(query.Url)
+
+	for ; index < len(lines); index++ {
+		line := strings.TrimSpace(lines[index])
+		if line == "" {
+			index++
+			break
+		}
+		if strings.HasPrefix(line, "#") || strings.HasPrefix(line, "//") {
+			continue
+		}
+		match := httpHeaderRegex.FindStringSubmatch(line)
+		if match == nil {
+			return nil, fmt.Errorf("invalid header in %s: %s", query.Name, line)
+		}
+		headerName := strings.ToLower(match[1])
+		if headerName == "cookie" {
+			parseCookies(resolve(match[2]), query.Cookie)
+			continue
+		}
+		query.Header[headerName] = resolve(match[2])
+	}
+
+	bodyLines := []string{}
+	for ; index < len(lines); index++ {
+		line := lines[index]
+		// a line starting with < includes the content of a file
+		if strings.HasPrefix(line, "< ") {
+			filePath := strings.TrimSpace(line[2:])
+			if !filepath.IsAbs(filePath) {
+				filePath = filepath.Join(dir, filePath)
+			}
+			content, err := os.ReadFile(filePath)
+			if err != nil {
+				return nil, fmt.Errorf("while reading the body of %s: %v", query.Name, err)
+			}
+			bodyLines = append(bodyLines, strings.TrimSuffix(string(content), "\n"))
+			continue
+		}
+		bodyLines = append(bodyLines, line)
+	}
+	query.Body = resolve(strings.TrimSpace(strings.Join(bodyLines, "\n")))
+	return &query, nil
+}
+
+// write the queries in the .http file format, as used by the VS Code REST Client and the
+// JetBrains http client. The %{var}% variables become {{var}} variables.
+// It also returns the list of what could not be converted
+func ExportHttpFile(queries []models.Query) (string, []string, error) {
+	warnings := []string{}
+	convert := func(s string) string {
+		return toBraceVariables(s, httpFileFormat, nil, &warnings)
+	}
+	var file strings.Builder
+	for index, query := range queries {
+		if index > 0 {
+			file.WriteString("\n")
+		}
+		fmt.Fprintf(&file, "### %s\n", query.Name)
+		for _, line := range strings.Split(strings.TrimSpace(query.Description), "\n") {
+			if line != "" {
+				fmt.Fprintf(&file, "# %s\n", line)
+			}
+		}
+		fmt.Fprintf(&file, "# @name %s\n", sanitizeVariableName(path.Base(query.Name)))
+
+		header := maps.Clone(query.Header)
+		if header == nil {
+			header = models.JSONMap{}
+		}
+		body := query.Body
+		requestUrl := convert(query.Url)
+		dataInBody := query.HasBody() && query.Body == "" && len(query.Data) > 0
+		keys := slices.Sorted(maps.Keys(query.Data))
+		if len(query.Data) > 0 && !dataInBody {
+			params := []string{}
+			for _, key := range keys {
+				params = append(params, url.QueryEscape(key)+"="+toBraceVariables(query.Data[key], httpFileFormat, url.QueryEscape, &warnings))
+			}
+			separator := "?"
+			if strings.Contains(requestUrl, "?") {
+				separator = "&"
+			}
+			requestUrl += separator + strings.Join(params, "&")
+		}
+		if dataInBody {
+			switch {
+			case query.IsMultipart:
+				boundary := "gourl-boundary"
+				header["content-type"] = "multipart/form-data; boundary=" + boundary
+				var parts strings.Builder
+				for _, key := range keys {
+					value := query.Data[key]
+					if filePath, isFile := models.MultipartFile(value); isFile {
+						fmt.Fprintf(&parts, "--%s\nContent-Disposition: form-data; name=%q; filename=%q\n\n< %s\n", boundary, key, filepath.Base(filePath), filePath)
+						continue
+					}
+					fmt.Fprintf(&parts, "--%s\nContent-Disposition: form-data; name=%q\n\n%s\n", boundary, key, models.MultipartText(value))
+				}
+				fmt.Fprintf(&parts, "--%s--", boundary)
+				body = convert(parts.String())
+			case query.IsJson:
+				encoded, err := json.MarshalIndent(query.Data, "", "  ")
+				if err != nil {
+					return "", nil, fmt.Errorf("while encoding the data of %s: %v", query.Name, err)
+				}
+				setDefaultHeader(header, "content-type", "application/json")
+				body = convert(string(encoded))
+			default:
+				params := []string{}
+				for _, key := range keys {
+					params = append(params, url.QueryEscape(key)+"="+toBraceVariables(query.Data[key], httpFileFormat, url.QueryEscape, &warnings))
+				}
+				setDefaultHeader(header, "content-type", "application/x-www-form-urlencoded")
+				body = strings.Join(params, "&")
+			}
+		} else {
+			body = convert(body)
+		}
+
+		fmt.Fprintf(&file, "%s %s\n", query.Method, requestUrl)
+		for _, key := range slices.Sorted(maps.Keys(header)) {
+			fmt.Fprintf(&file, "%s: %s\n", key, convert(header[key]))
+		}
+		if len(query.Cookie) > 0 {
+			cookies := []string{}
+			for _, key := range slices.Sorted(maps.Keys(query.Cookie)) {
+				cookies = append(cookies, key+"="+query.Cookie[key])
+			}
+			fmt.Fprintf(&file, "cookie: %s\n", convert(strings.Join(cookies, "; ")))
+		}
+		if body != "" {
+			fmt.Fprintf(&file, "\n%s\n", body)
+		}
+	}
+	return file.String(), warnings, nil
+}
@@ -0,0 +1,145 @@
+package convert
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nakurai/gourl/models"
+)
+
+func TestParseHttpFile(t *testing.T) {
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "user.json"), []byte("{\"name\": \"{{user}}\"}\n"), 0644)
+	if err != nil {
+		t.Errorf("%v\n", err)
+		return
+	}
+	queries, warnings, err := ParseHttpFile(`@api = {{host}}/v1
+@user = john
+
+### List the users
+GET {{api}}/users
+    ?page=2
+    &limit={{limit}}
+Accept: application/json
+Cookie: session={{session}}
+
+###
+# @name createUser
+// a comment
+POST {{api}}/users HTTP/1.1
+Content-Type: application/json
+
+< ./user.json
+
+### Get the created user
+https://example.com/users/{{createUser.response.body.$.id}}
+`, dir)
+	if err != nil {
+		t.Errorf("%v\n", err)
+		return
+	}
+	if len(queries) != 3 {
+		t.Errorf("3 requests should be parsed, not %d\n", len(queries))
+		return
+	}
+
+	list := queries[0]
+	if list.Name != "List the users" || list.Method != "GET" || list.Url != "%{host}%/v1/users?page=2&limit=%{limit}%" {
+		t.Errorf("unexpected request: %s %s %s\n", list.Name, list.Method, list.Url)
+	}
+	if list.Header["accept"] != "application/json" || list.Cookie["session"] != "%{session}%" || list.Body != "" {
+		t.Errorf("unexpected headers, cookies or body: %v %v %s\n", list.Header, list.Cookie, list.Body)
+	}
+
+	create := queries[1]
+	if create.Name != "createUser" || create.Method != "POST" || create.Body != `{"name": "john"}` || create.Header["content-type"] != "application/json" {
+		t.Errorf("unexpected request: %s %s %s %v\n", create.Name, create.Method, create.Body, create.Header)
+	}
+
+	if queries[2].Method != "GET" || len(warnings) != 1 || !strings.Contains(warnings[0], "createUser.response") {
+		t.Errorf("the request variables should be reported: %s %v\n", queries[2].Method, warnings)
+	}
+
+	queries, _, err = ParseHttpFile(`@user = john
+
+POST https://example.com/users
+
+@user = admin
+{"name": "{{user}}"}
+`, dir)
+	if err != nil {
+		t.Errorf("%v\n", err)
+		return
+	}
+	if len(queries) != 1 || queries[0].Body != "@user = admin\n{\"name\": \"john\"}" {
+		t.Errorf("the lines of the body should not define file variables: %v\n", queries)
+	}
+}
+
+func TestExportHttpFile(t *testing.T) {
+	content, _, err := ExportHttpFile([]models.Query{
+		{Name: "demo/search", Method: "GET", Url: "%{host}%/search", Description: "Search the users", Data: models.JSONMap{"q": "a b", "page": "%{page}%"}, Header: models.JSONMap{"x-token": "%{token}%"}},
+		{Name: "demo/create", Method: "POST", Url: "%{host}%/users", Data: models.JSONMap{"name": "%{name}%"}, Cookie: models.JSONMap{"session": "1"}},
+	})
+	if err != nil {
+		t.Errorf("%v\n", err)
+		return
+	}
+	expected := `### demo/search
+# Search the users
+# @name search
+GET {{host}}/search?page={{page}}&q=a+b
+x-token: {{token}}
+
+### demo/create
+# @name create
+POST {{host}}/users
+content-type: application/x-www-form-urlencoded
+cookie: session=1
+
+name={{name}}
+`
+	if content != expected {
+		t.Errorf("the export should be\n%s\nnot\n%s\n", expected, content)
+	}
+
+	queries, _, err := ParseHttpFile(content, "")
+	if err != nil || len(queries) != 2 || queries[1].Body != "name=%{name}%" || queries[0].Url != "%{host}%/search?page=%{page}%&q=a+b" {
+		t.Errorf("the export should be parsed back: %v %v\n", err, queries)
+	}
+}
+
+func TestRunExportedHttpFile(t *testing.T) {
+	content, _, err := ExportHttpFile([]models.Query{
+		{Name: "demo", Method: "POST", Url: "%{host:-http://x}%/users", Body: "literal %%{x}% for %{name}%", Header: models.JSONMap{"x-request": "%{$uuid}%"}},
+	})
+	if err != nil {
+		t.Errorf("%v\n", err)
+		return
+	}
+	queries, _, err := ParseHttpFile(content, t.TempDir())
+	if err != nil || len(queries) != 1 {
+		t.Errorf("the export should be parsed back: %v\n", err)
+		return
+	}
+
+	models.CurrentEnv = &models.Environment{Variables: map[string]string{"host": "https://example.com", "name": "john"}}
+	defer func() { models.CurrentEnv = nil }()
+	req, err := queries[0].NewRequest()
+	if err != nil {
+		t.Errorf("%v\n", err)
+		return
+	}
+	sent, err := io.ReadAll(req.Body)
+	if err != nil {
+		t.Errorf("%v\n", err)
+		return
+	}
+	if req.URL.String() != "https://example.com/users" || string(sent) != "literal %{x}% for john" || len(req.Header.Get("x-request")) != 36 {
+		t.Errorf("the exported query should be sent like the saved one, not %s %s %v\n", req.URL, sent, req.Header)
+	}
+}
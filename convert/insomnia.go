@@ -0,0 +1,425 @@
+package convert
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nakurai/gourl/models"
+)
+
+// the parts of the insomnia v4 export format gourl understands
+type insomniaExport struct {
+	Type         string             `json:"_type"`
+	ExportFormat int                `json:"__export_format"`
+	ExportDate   string             `json:"__export_date,omitempty"`
+	ExportSource string             `json:"__export_source,omitempty"`
+	Resources    []insomniaResource `json:"resources"`
+}
+
+// all the resources share the same structure, their type tells which fields are used
+type insomniaResource struct {
+	Id             string          `json:"_id"`
+	Type           string          `json:"_type"`
+	ParentId       *string         `json:"parentId"`
+	Name           string          `json:"name"`
+	Description    string          `json:"description,omitempty"`
+	Method         string          `json:"method,omitempty"`
+	Url            string          `json:"url,omitempty"`
+	Body           *insomniaBody   `json:"body,omitempty"`
+	Headers        []insomniaParam `json:"headers,omitempty"`
+	Parameters     []insomniaParam `json:"parameters,omitempty"`
+	Authentication map[string]any  `json:"authentication,omitempty"`
+	Data           map[string]any  `json:"data,omitempty"`
+}
+
+type insomniaBody struct {
+	MimeType string          `json:"mimeType,omitempty"`
+	Text     string          `json:"text,omitempty"`
+	Params   []insomniaParam `json:"params,omitempty"`
+	FileName string          `json:"fileName,omitempty"`
+}
+
+type insomniaParam struct {
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	Disabled bool   `json:"disabled,omitempty"`
+	Type     string `json:"type,omitempty"`
+	FileName string `json:"fileName,omitempty"`
+}
+
+// convert an insomnia v4 export into queries and environments. Request groups are
+// converted into the slash separated names of the queries, under the prefix (or the
+// name of the workspace). The variables of the base environment are merged into each
+// sub environment. It also returns the list of what could not be converted
+func ParseInsomnia(content []byte, prefix string) ([]models.Query, []models.Environment, []string, error) {
+	var export insomniaExport
+	err := json.Unmarshal(content, &export)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("invalid insomnia export: %v", err)
+	}
+	if export.Type != "export" || export.ExportFormat != 4 {
+		return nil, nil, nil, fmt.Errorf("only the insomnia v4 export format is supported")
+	}
+
+	warnings := []string{}
+	byId := map[string]insomniaResource{}
+	for _, resource := range export.Resources {
+		byId[resource.Id] = resource
+	}
+	parentOf := func(resource insomniaResource) (insomniaResource, bool) {
+		if resource.ParentId == nil {
+			return insomniaResource{}, false
+		}
+		parent, ok := byId[*resource.ParentId]
+		return parent, ok
+	}
+
+	// return the name of the query: the names of the request groups, under the workspace
+	queryName := func(resource insomniaResource) string {
+		parts := []string{sanitizeQueryName(resource.Name)}
+		for parent, ok := parentOf(resource); ok; parent, ok = parentOf(parent) {
+			if parent.Type == "workspace" {
+				if prefix == "" {
+					parts = append([]string{sanitizeQueryName(parent.Name)}, parts...)
+				}
+				break
+			}
+			parts = append([]string{sanitizeQueryName(parent.Name)}, parts...)
+		}
+		if prefix != "" {
+			parts = append([]string{strings.Trim(prefix, "/")}, parts...)
+		}
+		return strings.Join(parts, "/")
+	}
+
+	queries := []models.Query{}
+	usedNames := map[string]bool{}
+	baseEnvs := []insomniaResource{}
+	subEnvs := map[string][]insomniaResource{}
+	for _, resource := range export.Resources {
+		switch resource.Type {
+		case "request":
+			name := queryName(resource)
+			uniqueName := name
+			for index := 2; usedNames[uniqueName]; index++ {
+				uniqueName = fmt.Sprintf("%s (%d)", name, index)
+			}
+			usedNames[uniqueName] = true
+			queries = append(queries, convertInsomniaRequest(resource, uniqueName, &warnings))
+		case "environment":
+			if parent, ok := parentOf(resource); ok && parent.Type == "environment" {
+				subEnvs[parent.Id] = append(subEnvs[parent.Id], resource)
+			} else {
+				baseEnvs = append(baseEnvs, resource)
+			}
+		case "grpc_request", "websocket_request", "unit_test", "unit_test_suite":
+			warnings = append(warnings, fmt.Sprintf("the %s %s is not supported", strings.ReplaceAll(resource.Type, "_", " "), resource.Name))
+		}
+	}
+
+	envs := []models.Environment{}
+	for _, base := range baseEnvs {
+		baseVariables := flattenInsomniaData(base.Data, &warnings)
+		if len(subEnvs[base.Id]) == 0 {
+			if len(baseVariables) == 0 {
+				continue
+			}
+			// only the base environment, named after its workspace
+			name := base.Name
+			if workspace, ok := parentOf(base); ok {
+				name = workspace.Name
+			}
+			envs = append(envs, models.Environment{Name: name, Description: "imported from insomnia", Variables: baseVariables})
+			continue
+		}
+		for _, sub := range subEnvs[base.Id] {
+			variables := models.JSONMap{}
+			for key, value := range baseVariables {
+				variables[key] = value
+			}
+			for key, value := range flattenInsomniaData(sub.Data, &warnings) {
+				variables[key] = value
+			}
+			envs = append(envs, models.Environment{Name: sub.Name, Description: "imported from insomnia", Variables: variables})
+		}
+	}
+	return queries, envs, warnings, nil
+}
+
+// flatten the nested values of an environment. {"api": {"host": "x"}} is used as
+// {{ _.api.host }} in insomnia, it becomes the api_host variable
+func flattenInsomniaData(data map[string]any, warnings *[]string) models.JSONMap {
+	variables := models.JSONMap{}
+	var flatten func(prefix string, value any)
+	flatten = func(prefix string, value any) {
+		switch v := value.(type) {
+		case map[string]any:
+			for key, subValue := range v {
+				flatten(prefix+"_"+sanitizeVariableName(key), subValue)
+			}
+		case string:
+			variables[prefix] = convertBraceVariables(v, warnings)
+		case nil:
+			variables[prefix] = ""
+		default:
+			encoded, _ := json.Marshal(v)
+			variables[prefix] = string(encoded)
+		}
+	}
+	for key, value := range data {
+		flatten(sanitizeVariableName(key), value)
+	}
+	return variables
+}
+
+func convertInsomniaRequest(resource insomniaResource, name string, warnings *[]string) models.Query {
+	convert := func(s string) string {
+		return convertBraceVariables(s, warnings)
+	}
+	query := models.Query{
+		Name:        name,
+		Method:      strings.ToUpper(resource.Method),
+		Url:         convert(resource.Url),
+		Description: resource.Description,
+		Data:        map[string]string{},
+		Header:      map[string]string{},
+		Cookie:      map[string]string{},
+	}
+	if query.Method == "" {
+		query.Method = "GET"
+	}
+
+	params := []string{}
+	for _, param := range resource.Parameters {
+		if !param.Disabled {
+			params = append(params, escapeVariables(param.Name)+"="+convert(param.Value))
+		}
+	}
+	if len(params) > 0 {
+		separator := "?"
+		if strings.Contains(query.Url, "?") {
+			separator = "&"
+		}
+		query.Url += separator + strings.Join(params, "&")
+	}
+
+	for _, header := range resource.Headers {
+		if header.Disabled || header.Name == "" {
+			continue
+		}
+		headerName := strings.ToLower(header.Name)
+		if headerName == "cookie" {
+			parseCookies(convert(header.Value), query.Cookie)
+			continue
+		}
+		query.Header[headerName] = convert(header.Value)
+	}
+
+	auth := resource.Authentication
+	authString := func(key string) string {
+		value, _ := auth[key].(string)
+		return convert(value)
+	}
+	if disabled, _ := auth["disabled"].(bool); len(auth) > 0 && !disabled {
+		switch auth["type"] {
+		case "none":
+		case "bearer":
+			tokenPrefix := authString("prefix")
+			if tokenPrefix == "" {
+				tokenPrefix = "Bearer"
+			}
+			setDefaultHeader(query.Header, "authorization", tokenPrefix+" "+authString("token"))
+		case "basic":
+			username := authString("username")
+			password := authString("password")
+			if hasVariables(username + password) {
+				*warnings = append(*warnings, fmt.Sprintf("the basic auth of %s uses variables, it cannot be encoded in advance and is ignored", name))
+			} else {
+				setDefaultHeader(query.Header, "authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(unescapeVariables(username+":"+password))))
+			}
+		case "apikey":
+			if authString("addTo") == "queryParams" {
+				separator := "?"
+				if strings.Contains(query.Url, "?") {
+					separator = "&"
+				}
+				query.Url += separator + authString("key") + "=" + authString("value")
+			} else {
+				setDefaultHeader(query.Header, strings.ToLower(authString("key")), authString("value"))
+			}
+		default:
+			*warnings = append(*warnings, fmt.Sprintf("the %v auth of %s is not supported", auth["type"], name))
+		}
+	}
+
+	if body := resource.Body; body != nil {
+		switch {
+		case body.MimeType == "application/x-www-form-urlencoded":
+			for _, param := range body.Params {
+				if !param.Disabled {
+					query.Data[param.Name] = convert(param.Value)
+				}
+			}
+			setDefaultHeader(query.Header, "content-type", body.MimeType)
+		case body.MimeType == "multipart/form-data":
+			query.IsMultipart = true
+			for _, param := range body.Params {
+				if param.Disabled {
+					continue
+				}
+				if param.Type == "file" {
+					query.Data[param.Name] = "@" + escapeVariables(param.FileName)
+				} else {
+					// a text starting with @ is not the path of a file to upload
+					query.Data[param.Name] = models.EscapeMultipartText(convert(param.Value))
+				}
+			}
+		case body.Text != "":
+			query.Body = convert(body.Text)
+			mimeType := body.MimeType
+			if mimeType == "application/graphql" {
+				mimeType = "application/json"
+			}
+			if mimeType != "" {
+				setDefaultHeader(query.Header, "content-type", mimeType)
+			}
+		case body.FileName != "":
+			*warnings = append(*warnings, fmt.Sprintf("the file body of %s is not supported", name))
+		}
+	}
+	return query
+}
+
+// convert the queries and the environments into an insomnia v4 export. The folders
+// of the queries become request groups, and the environments become sub environments
+// of an empty base environment. It also returns the list of what could not be converted
+func ExportInsomnia(queries []models.Query, envs []models.Environment) (string, []string, error) {
+	warnings := []string{}
+	workspaceId := "wrk_gourl"
+	baseEnvId := "env_gourl_base"
+	export := insomniaExport{
+		Type:         "export",
+		ExportFormat: 4,
+		ExportDate:   time.Now().UTC().Format(time.RFC3339),
+		ExportSource: "gourl",
+		Resources: []insomniaResource{
+			{Id: workspaceId, Type: "workspace", Name: "gourl", Description: "exported from gourl"},
+			{Id: baseEnvId, Type: "environment", ParentId: &workspaceId, Name: "Base Environment", Data: map[string]any{}},
+		},
+	}
+	convert := func(s string) string {
+		return toBraceVariables(s, insomniaFormat, nil, &warnings)
+	}
+
+	for index, env := range envs {
+		data := map[string]any{}
+		for key, value := range env.Variables {
+			data[key] = convert(value)
+		}
+		export.Resources = append(export.Resources, insomniaResource{
+			Id:       fmt.Sprintf("env_gourl_%d", index+1),
+			Type:     "environment",
+			ParentId: &baseEnvId,
+			Name:     env.Name,
+			Data:     data,
+		})
+	}
+
+	// one request group per folder, created before the requests they contain
+	folderIds := map[string]string{".": workspaceId}
+	folders := []string{}
+	for _, query := range queries {
+		for folder := path.Dir(query.Name); folder != "." && folderIds[folder] == ""; folder = path.Dir(folder) {
+			folderIds[folder] = "pending"
+			folders = append(folders, folder)
+		}
+	}
+	sort.Strings(folders)
+	for index, folder := range folders {
+		folderIds[folder] = fmt.Sprintf("fld_gourl_%d", index+1)
+	}
+	for _, folder := range folders {
+		parentId := folderIds[path.Dir(folder)]
+		export.Resources = append(export.Resources, insomniaResource{
+			Id:       folderIds[folder],
+			Type:     "request_group",
+			ParentId: &parentId,
+			Name:     path.Base(folder),
+		})
+	}
+
+	for index, query := range queries {
+		parentId := folderIds[path.Dir(query.Name)]
+		request := insomniaResource{
+			Id:          fmt.Sprintf("req_gourl_%d", index+1),
+			Type:        "request",
+			ParentId:    &parentId,
+			Name:        path.Base(query.Name),
+			Description: query.Description,
+			Method:      query.Method,
+			Url:         convert(query.Url),
+			Headers:     []insomniaParam{},
+			Parameters:  []insomniaParam{},
+			Body:        &insomniaBody{},
+		}
+		for _, key := range slices.Sorted(maps.Keys(query.Header)) {
+			request.Headers = append(request.Headers, insomniaParam{Name: key, Value: convert(query.Header[key])})
+		}
+		if len(query.Cookie) > 0 {
+			cookies := []string{}
+			for _, key := range slices.Sorted(maps.Keys(query.Cookie)) {
+				cookies = append(cookies, key+"="+convert(query.Cookie[key]))
+			}
+			request.Headers = append(request.Headers, insomniaParam{Name: "Cookie", Value: strings.Join(cookies, "; ")})
+		}
+
+		dataParams := []insomniaParam{}
+		for _, key := range slices.Sorted(maps.Keys(query.Data)) {
+			value := query.Data[key]
+			if filePath, isFile := models.MultipartFile(value); query.IsMultipart && isFile {
+				dataParams = append(dataParams, insomniaParam{Name: key, Type: "file", FileName: filePath})
+				continue
+			}
+			if query.IsMultipart {
+				value = models.MultipartText(value)
+			}
+			dataParams = append(dataParams, insomniaParam{Name: key, Value: convert(value)})
+		}
+		switch {
+		case query.Body != "":
+			request.Body.MimeType = query.Header["content-type"]
+			request.Body.Text = convert(query.Body)
+			request.Parameters = dataParams
+		case !query.HasBody():
+			request.Parameters = dataParams
+		case len(dataParams) == 0:
+		case query.IsMultipart:
+			request.Body.MimeType = "multipart/form-data"
+			request.Body.Params = dataParams
+		case query.IsJson:
+			text, err := json.MarshalIndent(query.Data, "", "  ")
+			if err != nil {
+				return "", nil, fmt.Errorf("while encoding the data of %s: %v", query.Name, err)
+			}
+			request.Body.MimeType = "application/json"
+			request.Body.Text = convert(string(text))
+		default:
+			request.Body.MimeType = "application/x-www-form-urlencoded"
+			request.Body.Params = dataParams
+		}
+		export.Resources = append(export.Resources, request)
+	}
+
+	res, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return "", nil, fmt.Errorf("while encoding the insomnia export: %v", err)
+	}
+	return string(res) + "\n", warnings, nil
+}
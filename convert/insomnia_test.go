@@ -0,0 +1,94 @@
+package convert
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nakurai/gourl/models"
+)
+
+func TestParseInsomnia(t *testing.T) {
+	queries, envs, warnings, err := ParseInsomnia([]byte(`{"_type": "export", "__export_format": 4, "resources": [
+		{"_id": "wrk_1", "_type": "workspace", "parentId": null, "name": "Shop"},
+		{"_id": "fld_1", "_type": "request_group", "parentId": "wrk_1", "name": "orders"},
+		{"_id": "req_1", "_type": "request", "parentId": "fld_1", "name": "create", "method": "POST",
+			"url": "{{ _.api.host }}/orders", "parameters": [{"name": "dry", "value": "1"}],
+			"headers": [{"name": "X-Token", "value": "{{token}}"}, {"name": "X-Old", "value": "1", "disabled": true}],
+			"body": {"mimeType": "application/json", "text": "{\"id\": {% uuid 'v4' %}}"},
+			"authentication": {"type": "oauth2"}},
+		{"_id": "env_1", "_type": "environment", "parentId": "wrk_1", "name": "Base Environment", "data": {"api": {"host": "http://localhost"}, "token": "abc"}},
+		{"_id": "env_2", "_type": "environment", "parentId": "env_1", "name": "production", "data": {"api": {"host": "https://shop.example.com"}}}
+	]}`), "")
+	if err != nil {
+		t.Errorf("%v\n", err)
+		return
+	}
+	if len(queries) != 1 || queries[0].Name != "Shop/orders/create" {
+		t.Errorf("the request groups should be converted into folders, queries: %v\n", queries)
+		return
+	}
+	query := queries[0]
+	if query.Url != "%{api_host}%/orders?dry=1" || query.Header["x-token"] != "%{token}%" || query.Header["x-old"] != "" {
+		t.Errorf("unexpected url or headers: %s %v\n", query.Url, query.Header)
+	}
+	if query.Header["content-type"] != "application/json" {
+		t.Errorf("the mime type should be the content type, not %s\n", query.Header["content-type"])
+	}
+	if len(envs) != 1 || envs[0].Name != "production" || envs[0].Variables["api_host"] != "https://shop.example.com" || envs[0].Variables["token"] != "abc" {
+		t.Errorf("the base environment should be merged into the sub environments, envs: %v\n", envs)
+	}
+	summary := strings.Join(warnings, "\n")
+	if !strings.Contains(summary, "{% uuid 'v4' %}") || !strings.Contains(summary, "oauth2") {
+		t.Errorf("the template tags and auth types should be reported, warnings: %v\n", warnings)
+	}
+}
+
+func TestExportInsomnia(t *testing.T) {
+	queries := []models.Query{
+		{Name: "demo/users/create", Method: "POST", Url: "%{host}%/users", IsJson: true, Data: models.JSONMap{"name": "%{name}%"}, Header: models.JSONMap{"x-api": "2"}, Cookie: models.JSONMap{"session": "1"}},
+		{Name: "health", Method: "GET", Url: "%{host}%/health", Data: models.JSONMap{"full": "true"}},
+	}
+	envs := []models.Environment{{Name: "local", Variables: models.JSONMap{"host": "http://localhost"}}}
+	content, _, err := ExportInsomnia(queries, envs)
+	if err != nil {
+		t.Errorf("%v\n", err)
+		return
+	}
+
+	imported, importedEnvs, warnings, err := ParseInsomnia([]byte(content), "")
+	if err != nil || len(warnings) > 0 {
+		t.Errorf("the export should be importable: %v %v\n", err, warnings)
+		return
+	}
+	if len(imported) != 2 || imported[0].Name != "gourl/demo/users/create" || imported[1].Url != "%{host}%/health?full=true" {
+		t.Errorf("unexpected imported queries: %v\n", imported)
+		return
+	}
+	create := imported[0]
+	if !strings.Contains(create.Body, `"name": "%{name}%"`) || create.Header["x-api"] != "2" || create.Cookie["session"] != "1" {
+		t.Errorf("unexpected imported query: %s %v %v\n", create.Body, create.Header, create.Cookie)
+	}
+	if len(importedEnvs) != 1 || importedEnvs[0].Variables["host"] != "http://localhost" {
+		t.Errorf("unexpected imported environments: %v\n", importedEnvs)
+	}
+}
+
+func TestInsomniaMultipart(t *testing.T) {
+	query := models.Query{Name: "upload", Method: "POST", Url: "https://example.com/upload", IsMultipart: true, Data: models.JSONMap{"handle": "@@john", "avatar": "@./me.png"}}
+	content, _, err := ExportInsomnia([]models.Query{query}, nil)
+	if err != nil {
+		t.Errorf("%v\n", err)
+		return
+	}
+	if !strings.Contains(content, `"value": "@john"`) {
+		t.Errorf("the text should be exported without its escaping:\n%s", content)
+	}
+	imported, _, _, err := ParseInsomnia([]byte(content), "")
+	if err != nil || len(imported) != 1 {
+		t.Errorf("the export should be importable: %v\n", err)
+		return
+	}
+	if data := imported[0].Data; data["handle"] != "@@john" || data["avatar"] != "@./me.png" {
+		t.Errorf("only the file fields should be uploaded, not %v\n", data)
+	}
+}
@@ -0,0 +1,434 @@
+package convert
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/nakurai/gourl/models"
+	"gopkg.in/yaml.v3"
+)
+
+// the methods of an openapi path item, in the order the operations are converted
+var openApiMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+var pathParamRegex = regexp.MustCompile(`{([^{}]+)}`)
+
+// the openapi document is kept as a generic tree, to resolve the $ref wherever they are
+type openApiSpec struct {
+	root     map[string]any
+	warnings []string
+}
+
+// convert an openapi 3 specification (yaml or json) into queries, one per operation,
+// named <prefix>/<tag>/<operationId>, the prefix being the title of the api if empty, or openapi
+// without title. The url of the server becomes the base_url
+// variable, returned with its value. It also returns the list of what could not be converted
+func ParseOpenApi(content []byte, prefix string) ([]models.Query, map[string]string, []string, error) {
+	var root map[string]any
+	err := yaml.Unmarshal(content, &root)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("invalid openapi specification: %v", err)
+	}
+	version := fmt.Sprint(root["openapi"])
+	if !strings.HasPrefix(version, "3.") {
+		return nil, nil, nil, fmt.Errorf("only the openapi 3 specifications are supported, not %s", version)
+	}
+	spec := &openApiSpec{root: root, warnings: []string{}}
+
+	variables := map[string]string{"base_url": ""}
+	servers := asList(root["servers"])
+	if len(servers) > 0 {
+		variables["base_url"] = spec.serverUrl(asMap(servers[0]))
+	} else {
+		spec.warn("the specification has no server, set the base_url variable yourself")
+	}
+
+	prefix = strings.Trim(prefix, "/")
+	if prefix == "" {
+		prefix = "openapi"
+		if title, _ := asMap(root["info"])["title"].(string); strings.TrimSpace(title) != "" {
+			prefix = sanitizeQueryName(title)
+		}
+	}
+	queries := []models.Query{}
+	usedNames := map[string]bool{}
+	paths := asMap(root["paths"])
+	for _, apiPath := range sortedKeysOf(paths) {
+		pathItem := spec.resolve(paths[apiPath])
+		for _, method := range openApiMethods {
+			operation := asMap(pathItem[method])
+			if operation == nil {
+				continue
+			}
+			query := spec.convertOperation(apiPath, method, pathItem, operation)
+			name := query.Name
+			if prefix != "" {
+				name = prefix + "/" + name
+			}
+			uniqueName := name
+			for index := 2; usedNames[uniqueName]; index++ {
+				uniqueName = fmt.Sprintf("%s (%d)", name, index)
+			}
+			usedNames[uniqueName] = true
+			query.Name = uniqueName
+			queries = append(queries, query)
+		}
+	}
+	return queries, variables, spec.warnings, nil
+}
+
+func (s *openApiSpec) warn(format string, a ...any) {
+	s.warnings = append(s.warnings, fmt.Sprintf(format, a...))
+}
+
+// return the url of the server, its variables are replaced by their default value
+func (s *openApiSpec) serverUrl(server map[string]any) string {
+	serverVariables := asMap(server["variables"])
+	return strings.TrimSuffix(replaceAndEscape(pathParamRegex, fmt.Sprint(server["url"]), func(match string) string {
+		variable := asMap(serverVariables[match[1:len(match)-1]])
+		if variable == nil {
+			return escapeVariables(match)
+		}
+		return escapeVariables(fmt.Sprint(variable["default"]))
+	}), "/")
+}
+
+// follow the $ref of the node, if any. Only the references inside the document are supported
+func (s *openApiSpec) resolve(node any) map[string]any {
+	m := asMap(node)
+	for depth := 0; m != nil && depth < 32; depth++ {
+		ref, ok := m["$ref"].(string)
+		if !ok {
+			return m
+		}
+		if !strings.HasPrefix(ref, "#/") {
+			s.warn("the external reference %s is not supported", ref)
+			return nil
+		}
+		var target any = s.root
+		for _, part := range strings.Split(ref[2:], "/") {
+			part = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+			target = asMap(target)[part]
+		}
+		if target == nil {
+			s.warn("the reference %s does not exist", ref)
+		}
+		m = asMap(target)
+	}
+	return m
+}
+
+func (s *openApiSpec) convertOperation(apiPath string, method string, pathItem map[string]any, operation map[string]any) models.Query {
+	tags := []string{}
+	for _, tag := range asList(operation["tags"]) {
+		tags = append(tags, fmt.Sprint(tag))
+	}
+	folder := "default"
+	if len(tags) > 0 {
+		folder = sanitizeQueryName(tags[0])
+	}
+	operationId, _ := operation["operationId"].(string)
+	if operationId == "" {
+		operationId = method + strings.ReplaceAll(pathParamRegex.ReplaceAllString(apiPath, "$1"), "/", "_")
+	}
+
+	description := []string{}
+	for _, key := range []string{"summary", "description"} {
+		if text, ok := operation[key].(string); ok && text != "" {
+			description = append(description, strings.TrimSpace(text))
+		}
+	}
+	query := models.Query{
+		Name:        folder + "/" + sanitizeQueryName(operationId),
+		Method:      strings.ToUpper(method),
+		Description: strings.Join(description, "\n\n"),
+		Tags:        strings.Join(tags, ","),
+		Data:        map[string]string{},
+		Header:      map[string]string{},
+		Cookie:      map[string]string{},
+	}
+	if deprecated, _ := operation["deprecated"].(bool); deprecated {
+		s.warn("the operation %s is deprecated", query.Name)
+	}
+
+	// the parameters of the operation override the ones of the path
+	parameters := map[string]map[string]any{}
+	order := []string{}
+	for _, list := range []any{pathItem["parameters"], operation["parameters"]} {
+		for _, node := range asList(list) {
+			parameter := s.resolve(node)
+			if parameter == nil {
+				continue
+			}
+			key := fmt.Sprint(parameter["in"]) + ":" + fmt.Sprint(parameter["name"])
+			if _, ok := parameters[key]; !ok {
+				order = append(order, key)
+			}
+			parameters[key] = parameter
+		}
+	}
+
+	url := "%{base_url}%" + replaceAndEscape(pathParamRegex, apiPath, func(match string) string {
+		return "%{" + sanitizeVariableName(match[1:len(match)-1]) + "}%"
+	})
+	queryParams := []string{}
+	for _, key := range order {
+		parameter := parameters[key]
+		name := fmt.Sprint(parameter["name"])
+		required, _ := parameter["required"].(bool)
+		if parameter["in"] == "path" || !required {
+			continue
+		}
+		value := s.parameterValue(parameter)
+		switch parameter["in"] {
+		case "query":
+			queryParams = append(queryParams, escapeVariables(name)+"="+value)
+		case "header":
+			query.Header[strings.ToLower(name)] = value
+		case "cookie":
+			query.Cookie[name] = value
+		}
+	}
+	if len(queryParams) > 0 {
+		url += "?" + strings.Join(queryParams, "&")
+	}
+	query.Url = url
+
+	s.convertSecurity(&query, operation)
+	s.convertRequestBody(&query, operation)
+	return query
+}
+
+// return the example of the parameter, or a variable named after it
+func (s *openApiSpec) parameterValue(parameter map[string]any) string {
+	if example, ok := parameter["example"]; ok {
+		return escapeVariables(scalarString(example))
+	}
+	for _, name := range sortedKeysOf(asMap(parameter["examples"])) {
+		if example := s.resolve(asMap(parameter["examples"])[name]); example != nil {
+			return escapeVariables(scalarString(example["value"]))
+		}
+	}
+	schema := s.resolve(parameter["schema"])
+	for _, key := range []string{"example", "default"} {
+		if value, ok := schema[key]; ok {
+			return escapeVariables(scalarString(value))
+		}
+	}
+	return "%{" + sanitizeVariableName(fmt.Sprint(parameter["name"])) + "}%"
+}
+
+// set the authorization expected by the operation, or by the whole api
+func (s *openApiSpec) convertSecurity(query *models.Query, operation map[string]any) {
+	security, ok := operation["security"]
+	if !ok {
+		security = s.root["security"]
+	}
+	schemes := asMap(asMap(s.root["components"])["securitySchemes"])
+	for _, requirement := range asList(security) {
+		for _, name := range sortedKeysOf(asMap(requirement)) {
+			scheme := s.resolve(schemes[name])
+			if scheme == nil {
+				continue
+			}
+			variable := sanitizeVariableName(name)
+			switch {
+			case scheme["type"] == "http" && strings.EqualFold(fmt.Sprint(scheme["scheme"]), "bearer"):
+				setDefaultHeader(query.Header, "authorization", "Bearer %{"+variable+"}%")
+			case scheme["type"] == "http" && strings.EqualFold(fmt.Sprint(scheme["scheme"]), "basic"):
+				setDefaultHeader(query.Header, "authorization", "Basic %{"+variable+"}%")
+			case scheme["type"] == "apiKey" && scheme["in"] == "header":
+				setDefaultHeader(query.Header, strings.ToLower(fmt.Sprint(scheme["name"])), "%{"+variable+"}%")
+			case scheme["type"] == "apiKey" && scheme["in"] == "cookie":
+				query.Cookie[fmt.Sprint(scheme["name"])] = "%{" + variable + "}%"
+			case scheme["type"] == "apiKey" && scheme["in"] == "query":
+				separator := "?"
+				if strings.Contains(query.Url, "?") {
+					separator = "&"
+				}
+				query.Url += separator + escapeVariables(fmt.Sprint(scheme["name"])) + "=%{" + variable + "}%"
+			default:
+				s.warn("the %v security scheme %s of %s is not supported", scheme["type"], name, query.Name)
+			}
+		}
+		// only the first set of requirements is needed
+		return
+	}
+}
+
+// prefill the body with the example of the request, or with an example built from its schema
+func (s *openApiSpec) convertRequestBody(query *models.Query, operation map[string]any) {
+	requestBody := s.resolve(operation["requestBody"])
+	if requestBody == nil {
+		return
+	}
+	content := asMap(requestBody["content"])
+	mediaTypes := sortedKeysOf(content)
+	// the json content types are preferred
+	slices.SortStableFunc(mediaTypes, func(a, b string) int {
+		isJson := func(mediaType string) bool { return strings.Contains(mediaType, "json") }
+		if isJson(a) == isJson(b) {
+			return 0
+		}
+		if isJson(a) {
+			return -1
+		}
+		return 1
+	})
+	if len(mediaTypes) == 0 {
+		return
+	}
+	mediaTypeName := mediaTypes[0]
+	mediaType := asMap(content[mediaTypeName])
+
+	example, ok := mediaType["example"]
+	if !ok {
+		for _, name := range sortedKeysOf(asMap(mediaType["examples"])) {
+			if namedExample := s.resolve(asMap(mediaType["examples"])[name]); namedExample != nil {
+				example, ok = namedExample["value"], true
+				break
+			}
+		}
+	}
+	if !ok {
+		example = s.exampleFromSchema(mediaType["schema"], 0)
+	}
+
+	switch {
+	case strings.Contains(mediaTypeName, "json"):
+		body, err := json.MarshalIndent(example, "", "  ")
+		if err != nil {
+			s.warn("the example body of %s cannot be encoded: %v", query.Name, err)
+			return
+		}
+		query.Body = escapeVariables(string(body))
+		setDefaultHeader(query.Header, "content-type", mediaTypeName)
+	case mediaTypeName == "application/x-www-form-urlencoded", mediaTypeName == "multipart/form-data":
+		query.IsMultipart = mediaTypeName == "multipart/form-data"
+		properties := asMap(s.resolve(mediaType["schema"])["properties"])
+		for key, value := range asMap(example) {
+			property := s.resolve(properties[key])
+			if query.IsMultipart && property["format"] == "binary" {
+				query.Data[key] = "@./" + escapeVariables(key)
+				s.warn("the file %s of %s must be set to the path of the file to upload", key, query.Name)
+				continue
+			}
+			query.Data[key] = escapeVariables(scalarString(value))
+			if query.IsMultipart {
+				query.Data[key] = models.EscapeMultipartText(query.Data[key])
+			}
+		}
+		if !query.IsMultipart {
+			setDefaultHeader(query.Header, "content-type", mediaTypeName)
+		}
+	default:
+		if text, isText := example.(string); isText {
+			query.Body = escapeVariables(text)
+		}
+		setDefaultHeader(query.Header, "content-type", mediaTypeName)
+	}
+}
+
+// build an example value from the schema, using its examples and default values if any
+func (s *openApiSpec) exampleFromSchema(node any, depth int) any {
+	schema := s.resolve(node)
+	if schema == nil || depth > 8 {
+		return nil
+	}
+	for _, key := range []string{"example", "default"} {
+		if value, ok := schema[key]; ok {
+			return value
+		}
+	}
+	if values := asList(schema["examples"]); len(values) > 0 {
+		return values[0]
+	}
+	if values := asList(schema["enum"]); len(values) > 0 {
+		return values[0]
+	}
+	for _, key := range []string{"oneOf", "anyOf"} {
+		if choices := asList(schema[key]); len(choices) > 0 {
+			return s.exampleFromSchema(choices[0], depth+1)
+		}
+	}
+	if parts := asList(schema["allOf"]); len(parts) > 0 {
+		merged := map[string]any{}
+		for _, part := range parts {
+			for key, value := range asMap(s.exampleFromSchema(part, depth+1)) {
+				merged[key] = value
+			}
+		}
+		return merged
+	}
+
+	schemaType := schema["type"]
+	// openapi 3.1 allows a list of types, ex: [string, "null"]
+	if types := asList(schemaType); len(types) > 0 {
+		schemaType = types[0]
+	}
+	if schemaType == nil && schema["properties"] != nil {
+		schemaType = "object"
+	}
+	switch schemaType {
+	case "object":
+		object := map[string]any{}
+		properties := asMap(schema["properties"])
+		for _, key := range sortedKeysOf(properties) {
+			object[key] = s.exampleFromSchema(properties[key], depth+1)
+		}
+		return object
+	case "array":
+		return []any{s.exampleFromSchema(schema["items"], depth+1)}
+	case "integer", "number":
+		return 0
+	case "boolean":
+		return false
+	case "string":
+		switch schema["format"] {
+		case "date":
+			return "2024-01-01"
+		case "date-time":
+			return "2024-01-01T00:00:00Z"
+		case "email":
+			return "user@example.com"
+		case "uuid":
+			return "00000000-0000-0000-0000-000000000000"
+		case "uri", "url":
+			return "https://example.com"
+		}
+		return "string"
+	}
+	return nil
+}
+
+func asMap(node any) map[string]any {
+	m, _ := node.(map[string]any)
+	return m
+}
+
+func asList(node any) []any {
+	l, _ := node.([]any)
+	return l
+}
+
+func sortedKeysOf(m map[string]any) []string {
+	return slices.Sorted(maps.Keys(m))
+}
+
+// return the value as it would be written in a url or a header
+func scalarString(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case map[string]any, []any:
+		encoded, _ := json.Marshal(v)
+		return string(encoded)
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,98 @@
+package convert
+
+import (
+	"strings"
+	"testing"
+)
+
+const openApiSample = `
+openapi: 3.0.3
+info: {title: Pets, version: "1.0"}
+servers:
+  - url: https://{env}.example.com/v1/
+    variables:
+      env: {default: api}
+security:
+  - token: []
+paths:
+  /pets/{petId}:
+    parameters:
+      - {name: petId, in: path, required: true, schema: {type: integer}}
+    get:
+      operationId: getPet
+      tags: [pets]
+      summary: Get a pet
+      parameters:
+        - {name: fields, in: query, required: true, schema: {type: string, default: all}}
+        - {name: page, in: query, schema: {type: integer}}
+        - {name: X-Request-Id, in: header, required: true, schema: {type: string}}
+  /pets:
+    post:
+      tags: [pets]
+      requestBody:
+        content:
+          application/json:
+            schema: {$ref: "#/components/schemas/Pet"}
+components:
+  securitySchemes:
+    token: {type: http, scheme: bearer}
+  schemas:
+    Pet:
+      type: object
+      properties:
+        name: {type: string, example: rex}
+        age: {type: integer}
+        tags: {type: array, items: {type: string}}
+`
+
+func TestParseOpenApi(t *testing.T) {
+	queries, variables, warnings, err := ParseOpenApi([]byte(openApiSample), "petstore")
+	if err != nil {
+		t.Errorf("%v\n", err)
+		return
+	}
+	if variables["base_url"] != "https://api.example.com/v1" {
+		t.Errorf("the server url should use the default of its variables, not %s\n", variables["base_url"])
+	}
+	if len(queries) != 2 || len(warnings) != 0 {
+		t.Errorf("2 queries should be created without warnings, not %d: %v\n", len(queries), warnings)
+		return
+	}
+
+	create := queries[0]
+	if create.Name != "petstore/pets/post_pets" || create.Method != "POST" || create.Url != "%{base_url}%/pets" {
+		t.Errorf("unexpected query: %s %s %s\n", create.Name, create.Method, create.Url)
+	}
+	expectedBody := `{
+  "age": 0,
+  "name": "rex",
+  "tags": [
+    "string"
+  ]
+}`
+	if create.Body != expectedBody || create.Header["content-type"] != "application/json" {
+		t.Errorf("the body should be built from the schema, not %s\n", create.Body)
+	}
+
+	get := queries[1]
+	if get.Name != "petstore/pets/getPet" || get.Url != "%{base_url}%/pets/%{petId}%?fields=all" || get.Tags != "pets" {
+		t.Errorf("unexpected query: %s %s %s\n", get.Name, get.Url, get.Tags)
+	}
+	if get.Header["x-request-id"] != "%{X_Request_Id}%" || get.Header["authorization"] != "Bearer %{token}%" {
+		t.Errorf("the required headers and the security should be prefilled, not %v\n", get.Header)
+	}
+
+	_, _, _, err = ParseOpenApi([]byte(`{"swagger": "2.0"}`), "")
+	if err == nil || !strings.Contains(err.Error(), "openapi 3") {
+		t.Errorf("swagger 2 should not be supported, err: %v\n", err)
+	}
+
+	queries, _, _, err = ParseOpenApi([]byte(`{"openapi": "3.0.0", "paths": {"/health": {"get": {"operationId": "health"}}}}`), "")
+	if err != nil {
+		t.Errorf("%v\n", err)
+		return
+	}
+	if len(queries) != 1 || !strings.HasPrefix(queries[0].Name, "openapi/") {
+		t.Errorf("a specification without title should use the openapi prefix, not %v\n", queries)
+	}
+}
@@ -0,0 +1,415 @@
+package convert
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/nakurai/gourl/models"
+)
+
+// the parts of the postman collection v2.1 format gourl understands
+type postmanCollection struct {
+	Info struct {
+		Name   string `json:"name"`
+		Schema string `json:"schema"`
+	} `json:"info"`
+	Item     []postmanItem     `json:"item"`
+	Variable []postmanKeyValue `json:"variable"`
+	Auth     *postmanAuth      `json:"auth"`
+	Event    []postmanEvent    `json:"event"`
+}
+
+// either a folder (with items) or a request
+type postmanItem struct {
+	Name        string          `json:"name"`
+	Description json.RawMessage `json:"description"`
+	Item        []postmanItem   `json:"item"`
+	Request     json.RawMessage `json:"request"`
+	Auth        *postmanAuth    `json:"auth"`
+	Event       []postmanEvent  `json:"event"`
+}
+
+type postmanRequest struct {
+	Method      string            `json:"method"`
+	Header      []postmanKeyValue `json:"header"`
+	Body        *postmanBody      `json:"body"`
+	Url         json.RawMessage   `json:"url"`
+	Auth        *postmanAuth      `json:"auth"`
+	Description json.RawMessage   `json:"description"`
+}
+
+type postmanUrl struct {
+	Raw      string            `json:"raw"`
+	Protocol string            `json:"protocol"`
+	Host     json.RawMessage   `json:"host"`
+	Path     json.RawMessage   `json:"path"`
+	Query    []postmanKeyValue `json:"query"`
+}
+
+type postmanKeyValue struct {
+	Key      string `json:"key"`
+	Value    any    `json:"value"`
+	Disabled bool   `json:"disabled"`
+	Enabled  *bool  `json:"enabled"` // used by the environments instead of disabled
+	Type     string `json:"type"`
+	Src      any    `json:"src"`
+}
+
+type postmanBody struct {
+	Mode       string            `json:"mode"`
+	Raw        string            `json:"raw"`
+	Urlencoded []postmanKeyValue `json:"urlencoded"`
+	Formdata   []postmanKeyValue `json:"formdata"`
+	Graphql    *struct {
+		Query     string `json:"query"`
+		Variables string `json:"variables"`
+	} `json:"graphql"`
+	Options struct {
+		Raw struct {
+			Language string `json:"language"`
+		} `json:"raw"`
+	} `json:"options"`
+	Disabled bool `json:"disabled"`
+}
+
+type postmanAuth struct {
+	Type   string            `json:"type"`
+	Bearer []postmanKeyValue `json:"bearer"`
+	Basic  []postmanKeyValue `json:"basic"`
+	Apikey []postmanKeyValue `json:"apikey"`
+}
+
+type postmanEvent struct {
+	Listen string `json:"listen"`
+}
+
+type postmanEnvironment struct {
+	Name   string            `json:"name"`
+	Values []postmanKeyValue `json:"values"`
+}
+
+// return the value as a string, postman values can be numbers or booleans
+func (kv postmanKeyValue) stringValue() string {
+	if kv.Value == nil {
+		return ""
+	}
+	if s, ok := kv.Value.(string); ok {
+		return s
+	}
+	encoded, _ := json.Marshal(kv.Value)
+	return string(encoded)
+}
+
+// return the value of the key in the list, ex: the token of a bearer auth
+func postmanLookup(list []postmanKeyValue, key string) string {
+	for _, kv := range list {
+		if kv.Key == key {
+			return kv.stringValue()
+		}
+	}
+	return ""
+}
+
+// the description is either a string or an object with a content
+func postmanDescription(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	var description string
+	if json.Unmarshal(raw, &description) == nil {
+		return description
+	}
+	var object struct {
+		Content string `json:"content"`
+	}
+	json.Unmarshal(raw, &object)
+	return object.Content
+}
+
+// convert a postman collection v2.1 into queries. Folders are converted into the
+// slash separated names of the queries, under the prefix. It also returns the list
+// of what could not be converted (scripts, auth types, etc)
+func ParsePostmanCollection(content []byte, prefix string) ([]models.Query, []string, error) {
+	var collection postmanCollection
+	err := json.Unmarshal(content, &collection)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid postman collection: %v", err)
+	}
+	if collection.Info.Schema != "" && !strings.Contains(collection.Info.Schema, "v2.1") {
+		return nil, nil, fmt.Errorf("only the postman collection v2.1 format is supported, not %s", collection.Info.Schema)
+	}
+
+	warnings := []string{}
+	if len(collection.Event) > 0 {
+		warnings = append(warnings, "the scripts of the collection are not converted")
+	}
+	if len(collection.Variable) > 0 {
+		names := []string{}
+		for _, variable := range collection.Variable {
+			names = append(names, sanitizeVariableName(variable.Key))
+		}
+		warnings = append(warnings, fmt.Sprintf("the collection variables are not imported, add them to your environment with gourl var add: %s", strings.Join(names, ", ")))
+	}
+
+	queries := []models.Query{}
+	usedNames := map[string]bool{}
+	var walk func(items []postmanItem, folder string, auth *postmanAuth) error
+	walk = func(items []postmanItem, folder string, auth *postmanAuth) error {
+		for _, item := range items {
+			name := folder + "/" + sanitizeQueryName(item.Name)
+			if folder == "" {
+				name = sanitizeQueryName(item.Name)
+			}
+			itemAuth := auth
+			if item.Auth != nil {
+				itemAuth = item.Auth
+			}
+			if len(item.Event) > 0 {
+				warnings = append(warnings, fmt.Sprintf("the scripts of %s are not converted", name))
+			}
+			if len(item.Request) == 0 {
+				err := walk(item.Item, name, itemAuth)
+				if err != nil {
+					return err
+				}
+				continue
+			}
+
+			// two requests can have the same name in postman
+			uniqueName := name
+			for index := 2; usedNames[uniqueName]; index++ {
+				uniqueName = fmt.Sprintf("%s (%d)", name, index)
+			}
+			usedNames[uniqueName] = true
+
+			query, err := convertPostmanRequest(item, uniqueName, itemAuth, &warnings)
+			if err != nil {
+				return fmt.Errorf("while converting %s: %v", uniqueName, err)
+			}
+			queries = append(queries, *query)
+		}
+		return nil
+	}
+	err = walk(collection.Item, strings.Trim(prefix, "/"), collection.Auth)
+	if err != nil {
+		return nil, nil, err
+	}
+	return queries, warnings, nil
+}
+
+func convertPostmanRequest(item postmanItem, name string, auth *postmanAuth, warnings *[]string) (*models.Query, error) {
+	var request postmanRequest
+	// the request can be only the url
+	var rawUrl string
+	if json.Unmarshal(item.Request, &rawUrl) == nil {
+		request.Method = "GET"
+		request.Url, _ = json.Marshal(rawUrl)
+	} else {
+		err := json.Unmarshal(item.Request, &request)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	convert := func(s string) string {
+		return convertBraceVariables(s, warnings)
+	}
+	query := models.Query{
+		Name:        name,
+		Method:      strings.ToUpper(request.Method),
+		Data:        map[string]string{},
+		Header:      map[string]string{},
+		Cookie:      map[string]string{},
+		Description: postmanDescription(request.Description),
+	}
+	if query.Method == "" {
+		query.Method = "GET"
+	}
+	if query.Description == "" {
+		query.Description = postmanDescription(item.Description)
+	}
+
+	requestUrl, err := postmanRequestUrl(request.Url)
+	if err != nil {
+		return nil, err
+	}
+	query.Url = convert(requestUrl)
+
+	for _, header := range request.Header {
+		if header.Disabled {
+			continue
+		}
+		headerName := strings.ToLower(header.Key)
+		if headerName == "cookie" {
+			parseCookies(convert(header.stringValue()), query.Cookie)
+			continue
+		}
+		query.Header[headerName] = convert(header.stringValue())
+	}
+
+	if request.Auth != nil {
+		auth = request.Auth
+	}
+	if auth != nil {
+		switch auth.Type {
+		case "noauth":
+		case "bearer":
+			setDefaultHeader(query.Header, "authorization", "Bearer "+convert(postmanLookup(auth.Bearer, "token")))
+		case "basic":
+			// the credentials can contain variables, they are encoded when the query is sent
+			username := convert(postmanLookup(auth.Basic, "username"))
+			password := convert(postmanLookup(auth.Basic, "password"))
+			if hasVariables(username + password) {
+				*warnings = append(*warnings, fmt.Sprintf("the basic auth of %s uses variables, it cannot be encoded in advance and is ignored", name))
+			} else {
+				setDefaultHeader(query.Header, "authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(unescapeVariables(username+":"+password))))
+			}
+		case "apikey":
+			key := convert(postmanLookup(auth.Apikey, "key"))
+			value := convert(postmanLookup(auth.Apikey, "value"))
+			if postmanLookup(auth.Apikey, "in") == "query" {
+				separator := "?"
+				if strings.Contains(query.Url, "?") {
+					separator = "&"
+				}
+				query.Url += separator + key + "=" + value
+			} else {
+				setDefaultHeader(query.Header, strings.ToLower(key), value)
+			}
+		default:
+			*warnings = append(*warnings, fmt.Sprintf("the %s auth of %s is not supported", auth.Type, name))
+		}
+	}
+
+	if request.Body != nil && !request.Body.Disabled {
+		body := request.Body
+		switch body.Mode {
+		case "":
+		case "raw":
+			query.Body = convert(body.Raw)
+			if body.Options.Raw.Language == "json" {
+				setDefaultHeader(query.Header, "content-type", "application/json")
+			}
+		case "urlencoded":
+			for _, field := range body.Urlencoded {
+				if !field.Disabled {
+					query.Data[field.Key] = convert(field.stringValue())
+				}
+			}
+			setDefaultHeader(query.Header, "content-type", "application/x-www-form-urlencoded")
+		case "formdata":
+			query.IsMultipart = true
+			for _, field := range body.Formdata {
+				if field.Disabled {
+					continue
+				}
+				if field.Type != "file" {
+					// a text starting with @ is not the path of a file to upload
+					query.Data[field.Key] = models.EscapeMultipartText(convert(field.stringValue()))
+					continue
+				}
+				src, ok := field.Src.(string)
+				if !ok || src == "" {
+					*warnings = append(*warnings, fmt.Sprintf("the file %s of %s has no single path and is ignored", field.Key, name))
+					continue
+				}
+				query.Data[field.Key] = "@" + escapeVariables(src)
+			}
+		case "graphql":
+			if body.Graphql != nil {
+				graphqlBody := map[string]any{"query": body.Graphql.Query}
+				var variables any
+				if json.Unmarshal([]byte(body.Graphql.Variables), &variables) == nil {
+					graphqlBody["variables"] = variables
+				}
+				encoded, err := json.Marshal(graphqlBody)
+				if err != nil {
+					return nil, err
+				}
+				query.Body = convert(string(encoded))
+				setDefaultHeader(query.Header, "content-type", "application/json")
+			}
+		default:
+			*warnings = append(*warnings, fmt.Sprintf("the %s body of %s is not supported", body.Mode, name))
+		}
+	}
+	return &query, nil
+}
+
+// the url is either a string or an object
+func postmanRequestUrl(raw json.RawMessage) (string, error) {
+	if len(raw) == 0 {
+		return "", fmt.Errorf("the request has no url")
+	}
+	var rawUrl string
+	if json.Unmarshal(raw, &rawUrl) == nil {
+		return rawUrl, nil
+	}
+	var requestUrl postmanUrl
+	err := json.Unmarshal(raw, &requestUrl)
+	if err != nil {
+		return "", fmt.Errorf("invalid url: %v", err)
+	}
+	if requestUrl.Raw != "" {
+		return requestUrl.Raw, nil
+	}
+
+	// host and path are either a string or a list of parts
+	joinParts := func(raw json.RawMessage, separator string) string {
+		var s string
+		if json.Unmarshal(raw, &s) == nil {
+			return s
+		}
+		var parts []string
+		json.Unmarshal(raw, &parts)
+		return strings.Join(parts, separator)
+	}
+	res := joinParts(requestUrl.Host, ".")
+	if requestUrl.Protocol != "" {
+		res = requestUrl.Protocol + "://" + res
+	}
+	if path := joinParts(requestUrl.Path, "/"); path != "" {
+		res += "/" + strings.TrimPrefix(path, "/")
+	}
+	params := []string{}
+	for _, param := range requestUrl.Query {
+		if !param.Disabled {
+			params = append(params, param.Key+"="+param.stringValue())
+		}
+	}
+	if len(params) > 0 {
+		res += "?" + strings.Join(params, "&")
+	}
+	return res, nil
+}
+
+// convert a postman environment into a gourl environment. It also returns
+// the list of what could not be converted
+func ParsePostmanEnvironment(content []byte) (*models.Environment, []string, error) {
+	var postmanEnv postmanEnvironment
+	err := json.Unmarshal(content, &postmanEnv)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid postman environment: %v", err)
+	}
+
+	warnings := []string{}
+	env := models.Environment{
+		Name:        postmanEnv.Name,
+		Description: "imported from postman",
+		Variables:   map[string]string{},
+	}
+	for _, value := range postmanEnv.Values {
+		if value.Enabled != nil && !*value.Enabled {
+			warnings = append(warnings, fmt.Sprintf("the variable %s is disabled and is not imported", value.Key))
+			continue
+		}
+		name := sanitizeVariableName(value.Key)
+		if name != value.Key {
+			warnings = append(warnings, fmt.Sprintf("the variable %s is renamed %s", value.Key, name))
+		}
+		env.Variables[name] = convertBraceVariables(value.stringValue(), &warnings)
+	}
+	return &env, warnings, nil
+}
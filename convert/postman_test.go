@@ -0,0 +1,118 @@
+package convert
+
+import (
+	"strings"
+	"testing"
+)
+
+const postmanCollectionSample = `{
+  "info": {"name": "Demo", "schema": "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"},
+  "auth": {"type": "bearer", "bearer": [{"key": "token", "value": "{{token}}", "type": "string"}]},
+  "item": [
+    {"name": "users", "item": [
+      {"name": "create", "event": [{"listen": "test"}], "request": {
+        "method": "POST",
+        "header": [{"key": "X-Api-Version", "value": "2"}, {"key": "X-Old", "value": "1", "disabled": true}],
+        "body": {"mode": "raw", "raw": "{\"name\": \"{{user-name}}\"}", "options": {"raw": {"language": "json"}}},
+        "url": {"raw": "{{host}}/users", "host": ["{{host}}"], "path": ["users"]}
+      }},
+      {"name": "login", "request": {
+        "method": "POST",
+        "auth": {"type": "oauth2"},
+        "body": {"mode": "urlencoded", "urlencoded": [{"key": "user", "value": "john"}]},
+        "url": "{{host}}/login"
+      }}
+    ]},
+    {"name": "health", "request": "https://example.com/health"}
+  ]
+}`
+
+func TestParsePostmanCollection(t *testing.T) {
+	queries, warnings, err := ParsePostmanCollection([]byte(postmanCollectionSample), "demo")
+	if err != nil {
+		t.Errorf("%v\n", err)
+		return
+	}
+	if len(queries) != 3 {
+		t.Errorf("3 queries should be imported, not %d\n", len(queries))
+		return
+	}
+
+	create := queries[0]
+	if create.Name != "demo/users/create" || create.Method != "POST" || create.Url != "%{host}%/users" {
+		t.Errorf("unexpected query: %s %s %s\n", create.Name, create.Method, create.Url)
+	}
+	if create.Body != `{"name": "%{user_name}%"}` || create.Header["content-type"] != "application/json" {
+		t.Errorf("the raw JSON body should be converted, not %s (%s)\n", create.Body, create.Header["content-type"])
+	}
+	if create.Header["authorization"] != "Bearer %{token}%" || create.Header["x-api-version"] != "2" {
+		t.Errorf("the headers should include the collection auth, not %v\n", create.Header)
+	}
+	if _, ok := create.Header["x-old"]; ok {
+		t.Errorf("the disabled headers should be skipped\n")
+	}
+
+	login := queries[1]
+	if login.Data["user"] != "john" || login.Header["authorization"] != "" {
+		t.Errorf("unexpected login query: %v %v\n", login.Data, login.Header)
+	}
+	if queries[2].Name != "demo/health" || queries[2].Method != "GET" {
+		t.Errorf("a request given as a url should be a GET, not %s %s\n", queries[2].Method, queries[2].Name)
+	}
+
+	summary := strings.Join(warnings, "\n")
+	if !strings.Contains(summary, "scripts of demo/users/create") || !strings.Contains(summary, "oauth2 auth of demo/users/login") {
+		t.Errorf("the scripts and auth types should be reported, warnings: %v\n", warnings)
+	}
+}
+
+func TestParsePostmanEnvironment(t *testing.T) {
+	env, warnings, err := ParsePostmanEnvironment([]byte(`{"name": "staging", "values": [
+		{"key": "host", "value": "https://staging.example.com", "enabled": true},
+		{"key": "api-key", "value": "{{secret}}", "enabled": true},
+		{"key": "old", "value": "1", "enabled": false}
+	]}`))
+	if err != nil {
+		t.Errorf("%v\n", err)
+		return
+	}
+	if env.Name != "staging" || env.Variables["host"] != "https://staging.example.com" || env.Variables["api_key"] != "%{secret}%" {
+		t.Errorf("unexpected environment: %s %v\n", env.Name, env.Variables)
+	}
+	if _, ok := env.Variables["old"]; ok || len(warnings) != 2 {
+		t.Errorf("the disabled variables should be skipped and reported, warnings: %v\n", warnings)
+	}
+}
+
+func TestPostmanFormdataText(t *testing.T) {
+	queries, _, err := ParsePostmanCollection([]byte(`{"info": {"name": "Demo"}, "item": [
+		{"name": "upload", "request": {"method": "POST", "url": "https://example.com/upload",
+			"body": {"mode": "formdata", "formdata": [
+				{"key": "path", "value": "@/etc/hostname", "type": "text"},
+				{"key": "avatar", "src": "./me.png", "type": "file"}
+			]}}}
+	]}`), "")
+	if err != nil {
+		t.Errorf("%v\n", err)
+		return
+	}
+	query := queries[0]
+	if query.Data["path"] != "@@/etc/hostname" || query.Data["avatar"] != "@./me.png" {
+		t.Errorf("only the file fields should be uploaded, not %v\n", query.Data)
+	}
+
+	delete(query.Data, "avatar")
+	req, err := query.NewRequest()
+	if err != nil {
+		t.Errorf("the text field should not be read as a file: %v\n", err)
+		return
+	}
+	err = req.ParseMultipartForm(1 << 20)
+	if err != nil {
+		t.Errorf("%v\n", err)
+		return
+	}
+	if req.FormValue("path") != "@/etc/hostname" || len(req.MultipartForm.File) > 0 {
+		t.Errorf("the text field should be sent as is, not %v %v\n", req.MultipartForm.Value, req.MultipartForm.File)
+	}
+}
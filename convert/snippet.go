@@ -0,0 +1,655 @@
+package convert
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/nakurai/gourl/models"
+)
+
+// languages a query can be exported to
+var SnippetLanguages = []string{"curl", "httpie", "go", "python-requests", "js-fetch"}
+
+// a string of the query that can contain variables
+type snippetValue []models.TemplatePart
+
+// a key/value pair of the query, ex: a header
+type snippetPair struct {
+	Key   string
+	Value snippetValue
+}
+
+// the content of a query, independent from the language it is exported to
+type snippetRequest struct {
+	Method    string
+	Url       snippetValue
+	Params    []snippetPair // sent in the url's query string
+	Headers   []snippetPair
+	Cookies   []snippetPair
+	Variables []string // the variables used by the query, when they are not expanded
+
+	// only one of the following is used
+	JsonFields      []snippetPair
+	FormFields      []snippetPair
+	MultipartFields []snippetPair
+	MultipartFiles  []snippetPair // the value is the path of the file
+	RawBody         snippetValue
+}
+
+// render the query in the language. If expand is true, the variables are replaced by their
+// value in the current environment, otherwise they are read from the environment variables
+// when the snippet is executed
+func ExportSnippet(query models.Query, language string, expand bool) (string, error) {
+	request, err := newSnippetRequest(query, expand)
+	if err != nil {
+		return "", err
+	}
+	switch language {
+	case "curl":
+		return curlSnippet(request), nil
+	case "httpie":
+		return httpieSnippet(request), nil
+	case "go":
+		return goSnippet(request), nil
+	case "python-requests":
+		return pythonSnippet(request), nil
+	case "js-fetch":
+		return jsSnippet(request), nil
+	default:
+		return "", fmt.Errorf("unknown language %s. It must be one of: %s", language, strings.Join(SnippetLanguages, ", "))
+	}
+}
+
+func newSnippetRequest(query models.Query, expand bool) (*snippetRequest, error) {
+	variables := []string{}
+	seen := map[string]bool{}
+	toValue := func(s string) (snippetValue, error) {
+		if expand {
+			expanded, err := models.ExpandVariable(s)
+			if err != nil {
+				return nil, err
+			}
+			return snippetValue{{Text: expanded}}, nil
+		}
+		parts := models.SplitVariables(s)
+		for index, part := range parts {
+			if !part.IsVariable {
+				continue
+			}
+			reference := models.ParseReference(part.Text)
+			// the functions and the composed names, ex: %{token_%{env}%}%, have no environment variable
+			if models.IsExpression(reference.Name) || strings.Contains(reference.Name, "%{") {
+				return nil, fmt.Errorf("%%{%s}%% is not a single variable, use --expand true to export it", part.Text)
+			}
+			// the built-in variables have no environment variable, their value is generated now
+			if models.IsDynamicVariable(reference.Name) {
+				value, err := models.ExpandVariable("%{" + part.Text + "}%")
+				if err != nil {
+					return nil, err
+				}
+				parts[index] = models.TemplatePart{Text: value}
+				continue
+			}
+			// the snippets cannot read the files the way gourl does
+			if strings.HasPrefix(reference.Name, "file:") {
+				return nil, fmt.Errorf("%%{%s}%% reads a file, use --expand true to export it", part.Text)
+			}
+			if reference.HasDefault && hasVariables(reference.Default) {
+				return nil, fmt.Errorf("the default value of %%{%s}%% uses variables, use --expand true to export it", part.Text)
+			}
+			// the snippets already read their variables from the environment variables
+			name := strings.TrimPrefix(reference.Name, "env:")
+			switch {
+			case reference.HasDefault:
+				part.Text = name + ":-" + reference.Default
+			case reference.Required:
+				part.Text = name + ":?" + reference.Message
+			default:
+				part.Text = name
+			}
+			parts[index] = part
+			if !seen[name] {
+				seen[name] = true
+				variables = append(variables, name)
+			}
+		}
+		return parts, nil
+	}
+	toPairs := func(m map[string]string) ([]snippetPair, error) {
+		keys := make([]string, 0, len(m))
+		for key := range m {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		pairs := []snippetPair{}
+		for _, key := range keys {
+			value, err := toValue(m[key])
+			if err != nil {
+				return nil, err
+			}
+			pairs = append(pairs, snippetPair{Key: key, Value: value})
+		}
+		return pairs, nil
+	}
+
+	var err error
+	request := snippetRequest{Method: strings.ToUpper(query.Method)}
+	request.Url, err = toValue(query.Url)
+	if err != nil {
+		return nil, err
+	}
+	request.Headers, err = toPairs(query.Header)
+	if err != nil {
+		return nil, err
+	}
+	request.Cookies, err = toPairs(query.Cookie)
+	if err != nil {
+		return nil, err
+	}
+
+	// the @ of the files to upload is read before the variables are expanded
+	dataValues := map[string]string{}
+	files := map[string]bool{}
+	for key, value := range query.Data {
+		filePath, isFile := models.MultipartFile(value)
+		switch {
+		case !query.IsMultipart:
+			dataValues[key] = value
+		case isFile:
+			dataValues[key] = filePath
+			files[key] = true
+		default:
+			dataValues[key] = models.MultipartText(value)
+		}
+	}
+	data, err := toPairs(dataValues)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case query.Body != "":
+		request.RawBody, err = toValue(query.Body)
+		if err != nil {
+			return nil, err
+		}
+		request.Params = data
+	case !query.HasBody():
+		request.Params = data
+	case query.IsMultipart:
+		for _, pair := range data {
+			if files[pair.Key] {
+				request.MultipartFiles = append(request.MultipartFiles, pair)
+			} else {
+				request.MultipartFields = append(request.MultipartFields, pair)
+			}
+		}
+		// the content type is set by the libraries, with the boundary of the parts
+		headers := []snippetPair{}
+		for _, header := range request.Headers {
+			if header.Key != "content-type" {
+				headers = append(headers, header)
+			}
+		}
+		request.Headers = headers
+	case query.IsJson:
+		request.JsonFields = data
+	default:
+		request.FormFields = data
+	}
+	request.Variables = variables
+	return &request, nil
+}
+
+// render the value as a shell word. Literal texts are single quoted and
+// variables are read from the environment variables, ex: 'https://'"${host}"'/api'
+func shellQuote(value snippetValue) string {
+	if len(value) == 0 {
+		return "''"
+	}
+	res := ""
+	for _, part := range value {
+		if part.IsVariable {
+			res += `"` + shellVariable(part.Text) + `"`
+		} else {
+			res += "'" + strings.ReplaceAll(part.Text, "'", `'\''`) + "'"
+		}
+	}
+	return res
+}
+
+var shellDoubleQuoteReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "`", "\\`")
+
+// render the reference to an environment variable, used in double quotes, ex: ${port:-"8080"}.
+// The default value is in its own double quotes, so it can contain } and '
+func shellVariable(text string) string {
+	reference := models.ParseReference(text)
+	switch {
+	case reference.HasDefault:
+		return "${" + reference.Name + `:-"` + shellDoubleQuoteReplacer.Replace(reference.Default) + `"}`
+	case reference.Required:
+		return "${" + reference.Name + `:?"` + shellDoubleQuoteReplacer.Replace(reference.Message) + `"}`
+	}
+	return "${" + reference.Name + "}"
+}
+
+// render the value as an expression, joining the literals and variables with +.
+// The variables are rendered from their reference, with the name of the environment
+// variable and its default value if any
+func concatExpr(value snippetValue, literal func(string) string, variable func(models.VariableReference) string) string {
+	if len(value) == 0 {
+		return literal("")
+	}
+	parts := []string{}
+	for _, part := range value {
+		if part.IsVariable {
+			parts = append(parts, variable(models.ParseReference(part.Text)))
+		} else {
+			parts = append(parts, literal(part.Text))
+		}
+	}
+	return strings.Join(parts, " + ")
+}
+
+// join the cookies the way they are sent in the Cookie header: a=b; c=d
+func cookieHeader(cookies []snippetPair) snippetValue {
+	res := snippetValue{}
+	for index, cookie := range cookies {
+		separator := "; "
+		if index == 0 {
+			separator = ""
+		}
+		res = append(res, models.TemplatePart{Text: separator + cookie.Key + "="})
+		res = append(res, cookie.Value...)
+	}
+	return mergeLiterals(res)
+}
+
+// prepend the key to the value, ex: key=value
+func keyValue(key string, separator string, value snippetValue) snippetValue {
+	return mergeLiterals(append(snippetValue{{Text: key + separator}}, value...))
+}
+
+func shellVariablesComment(variables []string) string {
+	if len(variables) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("# environment variables used: %s\n", strings.Join(variables, ", "))
+}
+
+func curlSnippet(request *snippetRequest) string {
+	methodOption := "-X " + request.Method
+	if request.Method == "HEAD" {
+		// with -X HEAD, curl waits for a body that never comes
+		methodOption = "-I"
+	}
+	lines := []string{"curl " + methodOption + " " + shellQuote(request.Url)}
+	if len(request.Params) > 0 {
+		// -G sends the data in the url, it cannot be used along with a raw body
+		paramOption := "--url-query "
+		if len(request.RawBody) == 0 {
+			lines = append(lines, "-G")
+			paramOption = "--data-urlencode "
+		}
+		for _, param := range request.Params {
+			lines = append(lines, paramOption+shellQuote(keyValue(param.Key, "=", param.Value)))
+		}
+	}
+	for _, header := range request.Headers {
+		lines = append(lines, "-H "+shellQuote(keyValue(header.Key, ": ", header.Value)))
+	}
+	if len(request.Cookies) > 0 {
+		lines = append(lines, "-b "+shellQuote(cookieHeader(request.Cookies)))
+	}
+	switch {
+	case len(request.RawBody) > 0:
+		lines = append(lines, "--data-raw "+shellQuote(request.RawBody))
+	case len(request.JsonFields) > 0:
+		lines = append(lines, "--data-raw "+shellJsonObject(request.JsonFields))
+	case len(request.FormFields) > 0:
+		for _, field := range request.FormFields {
+			lines = append(lines, "--data-urlencode "+shellQuote(keyValue(field.Key, "=", field.Value)))
+		}
+	}
+	for _, field := range request.MultipartFields {
+		lines = append(lines, "--form-string "+shellQuote(keyValue(field.Key, "=", field.Value)))
+	}
+	for _, file := range request.MultipartFiles {
+		lines = append(lines, "-F "+shellQuote(keyValue(file.Key, "=@", file.Value)))
+	}
+	preamble := shellVariablesComment(request.Variables)
+	for _, field := range request.JsonFields {
+		if slices.ContainsFunc(field.Value, func(part models.TemplatePart) bool { return part.IsVariable }) {
+			preamble += shellJsonEscape
+			break
+		}
+	}
+	return preamble + strings.Join(lines, " \\\n  ") + "\n"
+}
+
+// a shell function escaping the value of a variable inserted in a JSON string
+const shellJsonEscape = `json_escape() {
+  local s=${1//\\/\\\\}
+  s=${s//\"/\\\"}
+  s=${s//$'\n'/\\n}
+  s=${s//$'\r'/\\r}
+  s=${s//$'\t'/\\t}
+  printf '%s' "$s"
+}
+`
+
+// render the JSON object of the fields as a shell word. The variables are escaped
+// with the json_escape function, so their value can contain quotes
+func shellJsonObject(fields []snippetPair) string {
+	res := ""
+	for _, part := range jsonObjectTemplate(fields) {
+		if part.IsVariable {
+			res += `"$(json_escape "` + shellVariable(part.Text) + `")"`
+		} else {
+			res += shellQuote(snippetValue{part})
+		}
+	}
+	return res
+}
+
+// build the JSON object of the fields. The values of the variables are inserted
+// as is, they must be escaped when they are read
+func jsonObjectTemplate(fields []snippetPair) snippetValue {
+	res := snippetValue{{Text: "{"}}
+	for index, field := range fields {
+		key, _ := json.Marshal(field.Key)
+		prefix := ","
+		if index == 0 {
+			prefix = ""
+		}
+		res = append(res, models.TemplatePart{Text: prefix + string(key) + ":\""})
+		for _, part := range field.Value {
+			if part.IsVariable {
+				res = append(res, part)
+				continue
+			}
+			escaped, _ := json.Marshal(part.Text)
+			res = append(res, models.TemplatePart{Text: strings.TrimSuffix(strings.TrimPrefix(string(escaped), `"`), `"`)})
+		}
+		res = append(res, models.TemplatePart{Text: "\""})
+	}
+	res = append(res, models.TemplatePart{Text: "}"})
+	return mergeLiterals(res)
+}
+
+// merge the consecutive literal texts
+func mergeLiterals(value snippetValue) snippetValue {
+	res := snippetValue{}
+	for _, part := range value {
+		if len(res) > 0 && !part.IsVariable && !res[len(res)-1].IsVariable {
+			res[len(res)-1].Text += part.Text
+			continue
+		}
+		res = append(res, part)
+	}
+	return res
+}
+
+func httpieSnippet(request *snippetRequest) string {
+	args := []string{"http"}
+	switch {
+	case len(request.FormFields) > 0:
+		args = append(args, "--form")
+	case len(request.MultipartFields) > 0 || len(request.MultipartFiles) > 0:
+		args = append(args, "--multipart")
+	}
+	if len(request.RawBody) > 0 {
+		args = append(args, "--raw "+shellQuote(request.RawBody))
+	}
+	args = []string{strings.Join(append(args, request.Method, shellQuote(request.Url)), " ")}
+	for _, param := range request.Params {
+		args = append(args, shellQuote(keyValue(param.Key, "==", param.Value)))
+	}
+	for _, header := range request.Headers {
+		args = append(args, shellQuote(keyValue(header.Key, ":", header.Value)))
+	}
+	if len(request.Cookies) > 0 {
+		args = append(args, shellQuote(keyValue("Cookie", ":", cookieHeader(request.Cookies))))
+	}
+	for _, fields := range [][]snippetPair{request.JsonFields, request.FormFields, request.MultipartFields} {
+		for _, field := range fields {
+			args = append(args, shellQuote(keyValue(field.Key, "=", field.Value)))
+		}
+	}
+	for _, file := range request.MultipartFiles {
+		args = append(args, shellQuote(keyValue(file.Key, "@", file.Value)))
+	}
+	return shellVariablesComment(request.Variables) + strings.Join(args, " \\\n  ") + "\n"
+}
+
+func goSnippet(request *snippetRequest) string {
+	imports := map[string]bool{"fmt": true, "io": true, "net/http": true}
+	if len(request.Variables) > 0 {
+		imports["os"] = true
+	}
+	expr := func(value snippetValue) string {
+		return concatExpr(value, strconv.Quote, func(reference models.VariableReference) string {
+			if reference.HasDefault {
+				imports["cmp"] = true
+				return fmt.Sprintf("cmp.Or(os.Getenv(%s), %s)", strconv.Quote(reference.Name), strconv.Quote(reference.Default))
+			}
+			return fmt.Sprintf("os.Getenv(%s)", strconv.Quote(reference.Name))
+		})
+	}
+
+	code := ""
+	urlExpr := expr(request.Url)
+	if len(request.Params) > 0 {
+		// the url can already have a query string
+		imports["net/url"] = true
+		code += fmt.Sprintf("\tu, err := url.Parse(%s)\n\tif err != nil {\n\t\tpanic(err)\n\t}\n", urlExpr)
+		code += "\tparams := u.Query()\n"
+		for _, param := range request.Params {
+			code += fmt.Sprintf("\tparams.Set(%s, %s)\n", strconv.Quote(param.Key), expr(param.Value))
+		}
+		code += "\tu.RawQuery = params.Encode()\n\n"
+		urlExpr = "u.String()"
+	}
+
+	bodyExpr := "nil"
+	contentType := ""
+	switch {
+	case len(request.RawBody) > 0:
+		imports["strings"] = true
+		code += fmt.Sprintf("\tbody := strings.NewReader(%s)\n\n", expr(request.RawBody))
+		bodyExpr = "body"
+	case len(request.JsonFields) > 0:
+		imports["bytes"] = true
+		imports["encoding/json"] = true
+		code += "\tjsonBody, err := json.Marshal(map[string]string{\n"
+		for _, field := range request.JsonFields {
+			code += fmt.Sprintf("\t\t%s: %s,\n", strconv.Quote(field.Key), expr(field.Value))
+		}
+		code += "\t})\n\tif err != nil {\n\t\tpanic(err)\n\t}\n\tbody := bytes.NewReader(jsonBody)\n\n"
+		bodyExpr = "body"
+	case len(request.FormFields) > 0:
+		imports["net/url"] = true
+		imports["strings"] = true
+		code += "\tform := url.Values{}\n"
+		for _, field := range request.FormFields {
+			code += fmt.Sprintf("\tform.Set(%s, %s)\n", strconv.Quote(field.Key), expr(field.Value))
+		}
+		code += "\tbody := strings.NewReader(form.Encode())\n\n"
+		bodyExpr = "body"
+	case len(request.MultipartFields) > 0 || len(request.MultipartFiles) > 0:
+		imports["bytes"] = true
+		imports["mime/multipart"] = true
+		code += "\tbody := &bytes.Buffer{}\n\twriter := multipart.NewWriter(body)\n"
+		for _, field := range request.MultipartFields {
+			code += fmt.Sprintf("\twriter.WriteField(%s, %s)\n", strconv.Quote(field.Key), expr(field.Value))
+		}
+		for _, file := range request.MultipartFiles {
+			imports["os"] = true
+			imports["path/filepath"] = true
+			code += fmt.Sprintf("\t{\n\t\tpath := %s\n\t\tcontent, err := os.ReadFile(path)\n\t\tif err != nil {\n\t\t\tpanic(err)\n\t\t}\n", expr(file.Value))
+			code += fmt.Sprintf("\t\tpart, err := writer.CreateFormFile(%s, filepath.Base(path))\n\t\tif err != nil {\n\t\t\tpanic(err)\n\t\t}\n\t\tpart.Write(content)\n\t}\n", strconv.Quote(file.Key))
+		}
+		code += "\twriter.Close()\n\n"
+		bodyExpr = "body"
+		contentType = "writer.FormDataContentType()"
+	}
+
+	code += fmt.Sprintf("\treq, err := http.NewRequest(%s, %s, %s)\n\tif err != nil {\n\t\tpanic(err)\n\t}\n", strconv.Quote(request.Method), urlExpr, bodyExpr)
+	for _, header := range request.Headers {
+		code += fmt.Sprintf("\treq.Header.Set(%s, %s)\n", strconv.Quote(header.Key), expr(header.Value))
+	}
+	if contentType != "" {
+		code += fmt.Sprintf("\treq.Header.Set(\"Content-Type\", %s)\n", contentType)
+	}
+	for _, cookie := range request.Cookies {
+		code += fmt.Sprintf("\treq.AddCookie(&http.Cookie{Name: %s, Value: %s})\n", strconv.Quote(cookie.Key), expr(cookie.Value))
+	}
+	code += "\n\tres, err := http.DefaultClient.Do(req)\n\tif err != nil {\n\t\tpanic(err)\n\t}\n\tdefer res.Body.Close()\n"
+	code += "\tresBody, err := io.ReadAll(res.Body)\n\tif err != nil {\n\t\tpanic(err)\n\t}\n"
+	code += "\tfmt.Println(res.Status)\n\tfmt.Println(string(resBody))\n"
+
+	sortedImports := []string{}
+	for imp := range imports {
+		sortedImports = append(sortedImports, imp)
+	}
+	sort.Strings(sortedImports)
+	res := "package main\n\nimport (\n"
+	for _, imp := range sortedImports {
+		res += fmt.Sprintf("\t%s\n", strconv.Quote(imp))
+	}
+	res += ")\n\n"
+	if len(request.Variables) > 0 {
+		res += fmt.Sprintf("// environment variables used: %s\n", strings.Join(request.Variables, ", "))
+	}
+	return res + "func main() {\n" + code + "}\n"
+}
+
+func pythonSnippet(request *snippetRequest) string {
+	expr := func(value snippetValue) string {
+		return concatExpr(value, strconv.Quote, func(reference models.VariableReference) string {
+			if reference.HasDefault {
+				return fmt.Sprintf("os.environ.get(%s, %s)", strconv.Quote(reference.Name), strconv.Quote(reference.Default))
+			}
+			return fmt.Sprintf("os.environ[%s]", strconv.Quote(reference.Name))
+		})
+	}
+	dict := func(pairs []snippetPair, valueExpr func(snippetValue) string) string {
+		res := "{\n"
+		for _, pair := range pairs {
+			res += fmt.Sprintf("        %s: %s,\n", strconv.Quote(pair.Key), valueExpr(pair.Value))
+		}
+		return res + "    }"
+	}
+
+	res := ""
+	if len(request.Variables) > 0 || len(request.MultipartFiles) > 0 {
+		res += "import os\n"
+	}
+	res += "import requests\n\n"
+	if len(request.Variables) > 0 {
+		res += fmt.Sprintf("# environment variables used: %s\n", strings.Join(request.Variables, ", "))
+	}
+	res += "response = requests.request(\n"
+	res += fmt.Sprintf("    %s,\n    %s,\n", strconv.Quote(request.Method), expr(request.Url))
+	if len(request.Params) > 0 {
+		res += fmt.Sprintf("    params=%s,\n", dict(request.Params, expr))
+	}
+	if len(request.Headers) > 0 {
+		res += fmt.Sprintf("    headers=%s,\n", dict(request.Headers, expr))
+	}
+	if len(request.Cookies) > 0 {
+		res += fmt.Sprintf("    cookies=%s,\n", dict(request.Cookies, expr))
+	}
+	switch {
+	case len(request.RawBody) > 0:
+		res += fmt.Sprintf("    data=%s,\n", expr(request.RawBody))
+	case len(request.JsonFields) > 0:
+		res += fmt.Sprintf("    json=%s,\n", dict(request.JsonFields, expr))
+	case len(request.FormFields) > 0:
+		res += fmt.Sprintf("    data=%s,\n", dict(request.FormFields, expr))
+	}
+	if len(request.MultipartFields) > 0 || len(request.MultipartFiles) > 0 {
+		// requests only sends a multipart form when using files, the fields are files without name
+		res += "    files={\n"
+		for _, field := range request.MultipartFields {
+			res += fmt.Sprintf("        %s: (None, %s),\n", strconv.Quote(field.Key), expr(field.Value))
+		}
+		for _, file := range request.MultipartFiles {
+			res += fmt.Sprintf("        %s: (os.path.basename(%s), open(%s, \"rb\")),\n", strconv.Quote(file.Key), expr(file.Value), expr(file.Value))
+		}
+		res += "    },\n"
+	}
+	res += ")\nprint(response.status_code)\nprint(response.text)\n"
+	return res
+}
+
+func jsSnippet(request *snippetRequest) string {
+	jsString := func(s string) string {
+		encoded, _ := json.Marshal(s)
+		return string(encoded)
+	}
+	expr := func(value snippetValue) string {
+		return concatExpr(value, jsString, func(reference models.VariableReference) string {
+			if reference.HasDefault {
+				return fmt.Sprintf("(process.env[%s] ?? %s)", jsString(reference.Name), jsString(reference.Default))
+			}
+			return fmt.Sprintf("process.env[%s]", jsString(reference.Name))
+		})
+	}
+	object := func(pairs []snippetPair, indent string) string {
+		res := "{\n"
+		for _, pair := range pairs {
+			res += fmt.Sprintf("%s  %s: %s,\n", indent, jsString(pair.Key), expr(pair.Value))
+		}
+		return res + indent + "}"
+	}
+
+	res := ""
+	if len(request.MultipartFiles) > 0 {
+		res += "import { readFileSync } from \"node:fs\";\nimport { basename } from \"node:path\";\n\n"
+	}
+	if len(request.Variables) > 0 {
+		res += fmt.Sprintf("// environment variables used: %s\n", strings.Join(request.Variables, ", "))
+	}
+	res += fmt.Sprintf("const url = new URL(%s);\n", expr(request.Url))
+	for _, param := range request.Params {
+		res += fmt.Sprintf("url.searchParams.set(%s, %s);\n", jsString(param.Key), expr(param.Value))
+	}
+
+	body := ""
+	switch {
+	case len(request.RawBody) > 0:
+		body = expr(request.RawBody)
+	case len(request.JsonFields) > 0:
+		body = fmt.Sprintf("JSON.stringify(%s)", object(request.JsonFields, "  "))
+	case len(request.FormFields) > 0:
+		body = fmt.Sprintf("new URLSearchParams(%s)", object(request.FormFields, "  "))
+	case len(request.MultipartFields) > 0 || len(request.MultipartFiles) > 0:
+		res += "const form = new FormData();\n"
+		for _, field := range request.MultipartFields {
+			res += fmt.Sprintf("form.append(%s, %s);\n", jsString(field.Key), expr(field.Value))
+		}
+		for _, file := range request.MultipartFiles {
+			res += fmt.Sprintf("form.append(%s, new Blob([readFileSync(%s)]), basename(%s));\n", jsString(file.Key), expr(file.Value), expr(file.Value))
+		}
+		body = "form"
+	}
+
+	headers := request.Headers
+	if len(request.Cookies) > 0 {
+		headers = append(append([]snippetPair{}, headers...), snippetPair{Key: "cookie", Value: cookieHeader(request.Cookies)})
+	}
+	res += "\nconst response = await fetch(url, {\n"
+	res += fmt.Sprintf("  method: %s,\n", jsString(request.Method))
+	if len(headers) > 0 {
+		res += fmt.Sprintf("  headers: %s,\n", object(headers, "  "))
+	}
+	if body != "" {
+		res += fmt.Sprintf("  body: %s,\n", body)
+	}
+	res += "});\nconsole.log(response.status);\nconsole.log(await response.text());\n"
+	return res
+}
@@ -0,0 +1,125 @@
+package convert
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nakurai/gourl/models"
+)
+
+func TestExportSnippet(t *testing.T) {
+	query := models.Query{
+		Method: "GET",
+		Url:    "https://%{host}%/api/users",
+		Header: map[string]string{"authorization": "Bearer %{token}%"},
+		Data:   map[string]string{"name": "it's me"},
+		Cookie: map[string]string{},
+	}
+
+	snippet, err := ExportSnippet(query, "curl", false)
+	if err != nil {
+		t.Errorf("%v\n", err)
+		return
+	}
+	expected := `# environment variables used: host, token
+curl -X GET 'https://'"${host}"'/api/users' \
+  -G \
+  --data-urlencode 'name=it'\''s me' \
+  -H 'authorization: Bearer '"${token}"
+`
+	if snippet != expected {
+		t.Errorf("unexpected curl snippet:\n%s\nexpected:\n%s", snippet, expected)
+	}
+
+	models.CurrentEnv = &models.Environment{
+		Variables: map[string]string{"host": "example.com", "token": `"xyz"`},
+	}
+	snippet, err = ExportSnippet(query, "python-requests", true)
+	if err != nil {
+		t.Errorf("%v\n", err)
+		return
+	}
+	if !strings.Contains(snippet, `"https://example.com/api/users"`) || !strings.Contains(snippet, `"authorization": "Bearer \"xyz\""`) {
+		t.Errorf("the variables should be expanded and escaped:\n%s", snippet)
+	}
+	if strings.Contains(snippet, "os.environ") {
+		t.Errorf("expanded snippets should not read the environment variables:\n%s", snippet)
+	}
+
+	models.CurrentEnv = nil
+
+	query = models.Query{
+		Method: "GET",
+		Url:    "https://example.com/search?lang=en",
+		Data:   map[string]string{"q": "%{term}%"},
+		Header: map[string]string{},
+		Cookie: map[string]string{},
+	}
+	snippet, err = ExportSnippet(query, "go", false)
+	if err != nil {
+		t.Errorf("%v\n", err)
+		return
+	}
+	if !strings.Contains(snippet, `u, err := url.Parse("https://example.com/search?lang=en")`) || !strings.Contains(snippet, "u.RawQuery = params.Encode()") {
+		t.Errorf("the parameters should be added to the query string of the url:\n%s", snippet)
+	}
+
+	query = models.Query{
+		Method: "POST",
+		Url:    "https://example.com/messages",
+		IsJson: true,
+		Data:   map[string]string{"text": "say %{message}%"},
+		Header: map[string]string{},
+		Cookie: map[string]string{},
+	}
+	snippet, err = ExportSnippet(query, "curl", false)
+	if err != nil {
+		t.Errorf("%v\n", err)
+		return
+	}
+	if !strings.Contains(snippet, "json_escape() {") || !strings.Contains(snippet, `--data-raw '{"text":"say '"$(json_escape "${message}")"'"}'`) {
+		t.Errorf("the variables of the JSON body should be escaped:\n%s", snippet)
+	}
+
+	query = models.Query{
+		Method: "GET",
+		Url:    "%{host:-http://localhost}%/users/%{id:?the id is required}%",
+		Header: map[string]string{"x-home": "%{env:HOME:-it's \"}\"}%"},
+		Cookie: map[string]string{},
+	}
+	expectations := map[string][]string{
+		"curl":            {`"${host:-"http://localhost"}"'/users/'"${id:?"the id is required"}"`, `"${HOME:-"it's \"}\""}"`},
+		"go":              {`cmp.Or(os.Getenv("host"), "http://localhost") + "/users/" + os.Getenv("id")`, `"cmp"`},
+		"python-requests": {`os.environ.get("host", "http://localhost") + "/users/" + os.environ["id"]`},
+		"js-fetch":        {`(process.env["host"] ?? "http://localhost") + "/users/" + process.env["id"]`},
+	}
+	for language, expected := range expectations {
+		snippet, err = ExportSnippet(query, language, false)
+		if err != nil {
+			t.Errorf("%v\n", err)
+			continue
+		}
+		for _, code := range expected {
+			if !strings.Contains(snippet, code) {
+				t.Errorf("the %s snippet should read the default values with %s:\n%s", language, code, snippet)
+			}
+		}
+		if !strings.Contains(snippet, "environment variables used: host, id, HOME") {
+			t.Errorf("the %s snippet should list the names of the environment variables:\n%s", language, snippet)
+		}
+	}
+
+	query.Header = map[string]string{"authorization": "Bearer %{file:./token}%"}
+	_, err = ExportSnippet(query, "curl", false)
+	if err == nil || !strings.Contains(err.Error(), "--expand true") {
+		t.Errorf("the files should only be exported with --expand, not %v\n", err)
+	}
+
+	for _, header := range []string{`Basic %{base64(concat(user, ":", pass))}%`, "Bearer %{token_%{env}%}%"} {
+		query.Header = map[string]string{"authorization": header}
+		_, err = ExportSnippet(query, "go", false)
+		if err == nil || !strings.Contains(err.Error(), "--expand true") {
+			t.Errorf("%s should only be exported with --expand, not %v\n", header, err)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package convert
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/nakurai/gourl/models"
+)
+
+var braceVarRegex = regexp.MustCompile(`{{\s*([^{}]+?)\s*}}`)
+var templateTagRegex = regexp.MustCompile(`{%.*?%}`)
+var nonWordRegex = regexp.MustCompile(`\W`)
+
+// the dynamic variables of postman and the .http files matching a gourl built-in variable
+var builtInVariables = map[string]string{
+	"$guid":         "$uuid",
+	"$uuid":         "$uuid",
+	"$randomUUID":   "$uuid",
+	"$timestamp":    "$timestamp",
+	"$isoTimestamp": "$isoDate",
+	"$randomInt":    "$randomInt",
+	"$randomEmail":  "$randomEmail",
+}
+
+// convert the {{var}} variables used by postman, insomnia and the .http files into
+// %{var}% gourl variables. Characters not allowed in gourl variable names are replaced by _
+func convertBraceVariables(s string, warnings *[]string) string {
+	for _, tag := range templateTagRegex.FindAllString(s, -1) {
+		*warnings = append(*warnings, fmt.Sprintf("the template tag %s is not supported", tag))
+	}
+	return replaceAndEscape(braceVarRegex, s, func(match string) string {
+		name := braceVarRegex.FindStringSubmatch(match)[1]
+		if builtIn, ok := builtInVariables[name]; ok {
+			return "%{" + builtIn + "}%"
+		}
+		// the dynamic variables of the .http files taking arguments, ex: {{$randomInt 1 100}}
+		if fields := strings.Fields(name); len(fields) > 1 {
+			switch {
+			case fields[0] == "$processEnv" && len(fields) == 2:
+				return "%{env:" + fields[1] + "}%"
+			case fields[0] == "$datetime" && len(fields) == 2 && fields[1] == "iso8601":
+				return "%{$isoDate}%"
+			case fields[0] == "$randomInt" && len(fields) == 3:
+				return "%{$randomInt(" + fields[1] + "," + fields[2] + ")}%"
+			}
+		}
+		if strings.HasPrefix(name, "$") {
+			*warnings = append(*warnings, fmt.Sprintf("the dynamic variable %s is not supported", match))
+			return escapeVariables(match)
+		}
+		// insomnia prefixes its variables with _.
+		name = strings.TrimPrefix(name, "_.")
+		return "%{" + sanitizeVariableName(name) + "}%"
+	})
+}
+
+// escape the %{ of an imported text, so it is sent as is instead of being expanded,
+// ex: a body containing %{file:~/.ssh/id_rsa}% must not send the file
+func escapeVariables(s string) string {
+	return strings.ReplaceAll(s, "%{", "%%{")
+}
+
+// return the text of s without the escaping of its %{
+func unescapeVariables(s string) string {
+	return strings.ReplaceAll(s, "%%{", "%{")
+}
+
+// return true if s uses gourl variables, an escaped %%{ is not one
+func hasVariables(s string) bool {
+	return len(models.VariableNames(s)) > 0
+}
+
+// replace the matches of re by the result of replace, and escape the text between them
+func replaceAndEscape(re *regexp.Regexp, s string, replace func(match string) string) string {
+	res := ""
+	last := 0
+	for _, match := range re.FindAllStringIndex(s, -1) {
+		res += escapeVariables(s[last:match[0]]) + replace(s[match[0]:match[1]])
+		last = match[1]
+	}
+	return res + escapeVariables(s[last:])
+}
+
+// escape the values of a query imported as is, ex: from a curl command or a HAR file
+func escapeQueryVariables(query *models.Query) {
+	query.Url = escapeVariables(query.Url)
+	query.Body = escapeVariables(query.Body)
+	for _, values := range []map[string]string{query.Data, query.Header, query.Cookie} {
+		for key, value := range values {
+			values[key] = escapeVariables(value)
+		}
+	}
+}
+
+// a format using {{var}} variables, ex: the .http files
+type braceFormat struct {
+	variable   string            // how a variable is written, ex: {{%s}}
+	builtIns   map[string]string // the dynamic variables matching the gourl built-in variables
+	processEnv string            // the dynamic variable reading an environment variable, if any
+}
+
+var httpFileFormat = braceFormat{
+	variable: "{{%s}}",
+	builtIns: map[string]string{
+		"$uuid":        "$guid",
+		"$timestamp":   "$timestamp",
+		"$isoDate":     "$datetime iso8601",
+		"$randomInt":   "$randomInt",
+		"$randomEmail": "$randomEmail",
+	},
+	processEnv: "$processEnv",
+}
+
+var insomniaFormat = braceFormat{variable: "{{ _.%s }}"}
+
+// convert the %{var}% gourl variables into the {{var}} variables of the format, and the escaped
+// %%{ into literal %{. The literal texts are escaped by escape, if any. The references without
+// equivalent, ex: the functions, are kept as is, and what is lost is reported in warnings
+func toBraceVariables(s string, format braceFormat, escape func(string) string, warnings *[]string) string {
+	res := ""
+	for _, part := range models.SplitVariables(s) {
+		switch {
+		case part.IsVariable:
+			res += format.convertReference(part.Text, warnings)
+		case escape != nil:
+			res += escape(part.Text)
+		default:
+			res += part.Text
+		}
+	}
+	return res
+}
+
+func (f braceFormat) convertReference(expression string, warnings *[]string) string {
+	reference := models.ParseReference(expression)
+	name := reference.Name
+	asIs := "%{" + expression + "}%"
+	variable := ""
+	switch {
+	case models.IsExpression(name), strings.Contains(name, "%{"), strings.HasPrefix(name, "file:"):
+		addWarning(warnings, fmt.Sprintf("%s has no equivalent, it is exported as is", asIs))
+		return asIs
+	case strings.HasPrefix(name, "env:"):
+		if f.processEnv == "" {
+			addWarning(warnings, fmt.Sprintf("%s has no equivalent, it is exported as is", asIs))
+			return asIs
+		}
+		variable = f.processEnv + " " + strings.TrimPrefix(name, "env:")
+	case models.IsDynamicVariable(name):
+		// ex: $randomInt(1,100) becomes $randomInt 1 100
+		functionName, argList, _ := strings.Cut(strings.TrimSuffix(name, ")"), "(")
+		builtIn, ok := f.builtIns[functionName]
+		if !ok {
+			addWarning(warnings, fmt.Sprintf("the built-in variable %s has no equivalent, it is exported as is", asIs))
+			return asIs
+		}
+		variable = builtIn
+		for _, arg := range strings.Split(argList, ",") {
+			if arg = strings.TrimSpace(arg); arg != "" {
+				variable += " " + arg
+			}
+		}
+	default:
+		variable = name
+	}
+	if reference.HasDefault {
+		addWarning(warnings, fmt.Sprintf("the default value of %s is not exported", asIs))
+	}
+	return fmt.Sprintf(f.variable, variable)
+}
+
+// add the warning, unless it was already reported
+func addWarning(warnings *[]string, warning string) {
+	if !slices.Contains(*warnings, warning) {
+		*warnings = append(*warnings, warning)
+	}
+}
+
+// replace the characters not allowed in gourl variable names by _
+func sanitizeVariableName(name string) string {
+	return nonWordRegex.ReplaceAllString(name, "_")
+}
+
+// slashes separate the folders in gourl, they cannot be part of a name
+func sanitizeQueryName(name string) string {
+	name = strings.TrimSpace(strings.ReplaceAll(name, "/", "-"))
+	if name == "" {
+		return "unnamed"
+	}
+	return name
+}
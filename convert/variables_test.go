@@ -0,0 +1,102 @@
+package convert
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nakurai/gourl/models"
+)
+
+func TestImportedVariablesAreLiterals(t *testing.T) {
+	secretPath := filepath.Join(t.TempDir(), "id_rsa")
+	err := os.WriteFile(secretPath, []byte("private key"), 0600)
+	if err != nil {
+		t.Errorf("%v\n", err)
+		return
+	}
+	body := `{"key": "%{file:` + secretPath + `}%", "home": "%{env:HOME}%"}`
+
+	query, _, err := ParseCurl(`curl https://example.com/upload -H 'X-Key: %{file:` + secretPath + `}%' --data-raw '` + body + `'`)
+	if err != nil {
+		t.Errorf("%v\n", err)
+		return
+	}
+	req, err := query.NewRequest()
+	if err != nil {
+		t.Errorf("%v\n", err)
+		return
+	}
+	sent, err := io.ReadAll(req.Body)
+	if err != nil {
+		t.Errorf("%v\n", err)
+		return
+	}
+	if string(sent) != body {
+		t.Errorf("the imported body should be sent as is, not %s\n", sent)
+	}
+	if req.Header.Get("X-Key") != "%{file:"+secretPath+"}%" {
+		t.Errorf("the imported header should be sent as is, not %s\n", req.Header.Get("X-Key"))
+	}
+
+	queries, _, err := ParseHttpFile("POST https://example.com/upload\n\n"+body+"\n", t.TempDir())
+	if err != nil {
+		t.Errorf("%v\n", err)
+		return
+	}
+	req, err = queries[0].NewRequest()
+	if err != nil {
+		t.Errorf("%v\n", err)
+		return
+	}
+	sent, err = io.ReadAll(req.Body)
+	if err != nil {
+		t.Errorf("%v\n", err)
+		return
+	}
+	if string(sent) != body {
+		t.Errorf("the body of the .http file should be sent as is, not %s\n", sent)
+	}
+
+	queries, _, err = ParseHttpFile("GET https://example.com/{{file:~/.ssh/id_rsa}}\n", t.TempDir())
+	if err != nil {
+		t.Errorf("%v\n", err)
+		return
+	}
+	if queries[0].Url != "https://example.com/%{file____ssh_id_rsa}%" {
+		t.Errorf("the {{file:...}} variable should not become a file source, not %s\n", queries[0].Url)
+	}
+}
+
+func TestExportedVariables(t *testing.T) {
+	body := "literal %%{x}% and %{tok}% %{$uuid}% %{$randomInt(1,10)}% %{env:HOME}%"
+	content, warnings, err := ExportHttpFile([]models.Query{{
+		Name:   "demo",
+		Method: "POST",
+		Url:    "%{host:-http://x}%/a",
+		Header: models.JSONMap{"authorization": "Basic %{base64(user)}%"},
+		Body:   body,
+	}})
+	if err != nil {
+		t.Errorf("%v\n", err)
+		return
+	}
+	if !strings.Contains(content, "literal %{x}% and {{tok}} {{$guid}} {{$randomInt 1 10}} {{$processEnv HOME}}") || !strings.Contains(content, "POST {{host}}/a") {
+		t.Errorf("the variables should be exported as {{var}} variables:\n%s", content)
+	}
+	summary := strings.Join(warnings, "\n")
+	if !strings.Contains(summary, "default value of %{host:-http://x}%") || !strings.Contains(summary, "%{base64(user)}% has no equivalent") {
+		t.Errorf("the default values and functions should be reported, warnings: %v\n", warnings)
+	}
+
+	queries, _, err := ParseHttpFile(content, t.TempDir())
+	if err != nil || len(queries) != 1 {
+		t.Errorf("the export should be parsed back: %v\n", err)
+		return
+	}
+	if queries[0].Body != body || queries[0].Url != "%{host}%/a" {
+		t.Errorf("the variables should be parsed back, not %s %s\n", queries[0].Url, queries[0].Body)
+	}
+}
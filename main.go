@@ -5,7 +5,6 @@ import (
 	"os"
 
 	"github.com/nakurai/gourl/cli"
-	"github.com/nakurai/gourl/db"
 	"github.com/nakurai/gourl/models"
 	"github.com/nakurai/gourl/utils"
 )
@@ -18,48 +17,58 @@ func init() {
 	if version == "" {
 		version = "dev"
 	}
+}
 
-	err := utils.CreateDataDir()
-	if err != nil {
-		fmt.Printf("%v\n", err)
-		os.Exit(1)
-	}
-
-	err = db.Init()
+// find the workspace and load its data
+func setup(workspace string) error {
+	err := utils.CreateDataDir(workspace)
 	if err != nil {
-		fmt.Printf("%v\n", err)
-		os.Exit(1)
+		return err
 	}
+	return models.InitWorkspace()
+}
 
-	err = db.Db.AutoMigrate(&models.Query{}, &models.Environment{})
+func main() {
+	// the workspace is chosen before any command is executed
+	workspace, args, err := cli.ExtractFlag(os.Args[1:], "--workspace")
 	if err != nil {
-		fmt.Printf("%v\n", err)
+		fmt.Printf("error parsing the argument(s): %v\n", err)
 		os.Exit(1)
 	}
 
-	models.BuildQueryTree()
-
-	err = models.InitEnvironment()
-	if err != nil {
-		fmt.Printf("%v\n", err)
-		os.Exit(1)
+	// gourl init creates its own workspace
+	if len(args) == 0 || args[0] != "init" {
+		err = setup(workspace)
+		if err != nil {
+			fmt.Printf("%v\n", err)
+			os.Exit(1)
+		}
 	}
-}
 
-func main() {
-	
-	if len(os.Args) == 1 {
-		fmt.Printf("gourl v%s - https://github.com/nakurai/gourl\nUse gourl help for doc\n", version)
+	if len(args) == 0 {
+		fmt.Printf("gourl v%s - https://github.com/nakurai/gourl\nworkspace: %s (%s)\nUse gourl help for doc\n", version, utils.DataDirPath, utils.DataDirSource)
 		return
 	}
 
 	app := cli.NewCli()
-	err := app.Register([]cli.CmdInterface{
+	err = app.Register([]cli.CmdInterface{
 		&cli.RequestCmd{},
 		&cli.ListCmd{},
 		&cli.EnvCmd{},
 		&cli.VarCmd{},
 		&cli.LoadCmd{},
+		&cli.SearchCmd{},
+		&cli.PickCmd{},
+		&cli.DocsCmd{},
+		&cli.QueryCmd{},
+		&cli.ImportCmd{},
+		&cli.ExportCmd{},
+		&cli.RunCmd{},
+		&cli.SyncCmd{},
+		&cli.InitCmd{},
+		&cli.BackupCmd{},
+		&cli.RestoreCmd{},
+		&cli.VaultCmd{},
 	})
 	if err != nil {
 		fmt.Printf("error while registering cmds: %v\n", err)
@@ -67,7 +76,7 @@ func main() {
 
 	}
 
-	cmd := os.Args[1]
+	cmd := args[0]
 
 	if cmd == "help"{
 		fmt.Println(app.Help)
@@ -84,7 +93,7 @@ func main() {
 		return
 	}
 
-	actions, flags, err := app.ParseArgs(os.Args[2:])
+	actions, flags, err := app.ParseArgs(args[1:])
 	if err != nil {
 		fmt.Printf("error parsing the argument(s): %v\n", err)
 		return
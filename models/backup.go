@@ -0,0 +1,430 @@
+package models
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/nakurai/gourl/db"
+	"github.com/nakurai/gourl/utils"
+	"gorm.io/gorm"
+)
+
+// identify the bundles written by gourl backup, and the version of their format
+const (
+	BundleFormat  = "gourl-bundle"
+	BundleVersion = 1
+)
+
+// how the secret variables are written in a bundle
+const (
+	SecretsKeep    = "keep"
+	SecretsStrip   = "strip"
+	SecretsEncrypt = "encrypt"
+)
+
+// the prefix of the encrypted values in a bundle
+const encryptedPrefix = "enc:"
+
+// describe the content of a bundle, it is the first file of the archive
+type BundleManifest struct {
+	Format        string               `json:"format"`
+	Version       int                  `json:"version"`
+	CreatedAt     time.Time            `json:"created_at"`
+	Secrets       string               `json:"secrets"` // keep, strip or encrypt
+	KeyDerivation *BundleKeyDerivation `json:"key_derivation,omitempty"`
+	Files         map[string]string    `json:"files"` // name of the file: what it holds
+	Counts        map[string]int       `json:"counts"`
+}
+
+// how the key encrypting the secrets is derived from the passphrase
+type BundleKeyDerivation struct {
+	Algorithm  string `json:"algorithm"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+}
+
+// an example, linked to its query by name so it can be restored in another workspace
+type BundleExample struct {
+	QueryName string `json:"query_name"`
+	Example
+}
+
+type Bundle struct {
+	Manifest     BundleManifest
+	Queries      []Query
+	Environments []Environment
+	Examples     []BundleExample
+}
+
+// read all the queries, environments and examples of the workspace. The secret
+// variables are kept, stripped or encrypted with the passphrase
+func NewBundle(secrets string, passphrase string) (*Bundle, error) {
+	bundle := Bundle{Manifest: BundleManifest{
+		Format:    BundleFormat,
+		Version:   BundleVersion,
+		CreatedAt: time.Now().UTC(),
+		Secrets:   secrets,
+		Files: map[string]string{
+			"queries.json":      "the saved queries",
+			"environments.json": "the environments and their variables",
+			"examples.json":     "the example responses of the saved queries",
+		},
+	}}
+
+	var key []byte
+	switch secrets {
+	case SecretsKeep, SecretsStrip:
+	case SecretsEncrypt:
+		if passphrase == "" {
+			return nil, fmt.Errorf("a passphrase is needed to encrypt the secrets")
+		}
+		salt, err := utils.NewSalt()
+		if err != nil {
+			return nil, err
+		}
+		derivation := BundleKeyDerivation{Algorithm: "pbkdf2-sha256", Iterations: utils.KeyDerivationIterations, Salt: salt}
+		key, err = utils.DeriveKey(passphrase, derivation.Salt, derivation.Iterations)
+		if err != nil {
+			return nil, err
+		}
+		bundle.Manifest.KeyDerivation = &derivation
+	default:
+		return nil, fmt.Errorf("unknown secrets mode %s. It must be keep, strip or encrypt", secrets)
+	}
+
+	var err error
+	bundle.Queries, err = GetAllQueries()
+	if err != nil {
+		return nil, err
+	}
+	bundle.Environments, err = GetAllEnvs()
+	if err != nil {
+		return nil, err
+	}
+	secretCount := 0
+	for _, env := range bundle.Environments {
+		for name, value := range env.Variables {
+			if !utils.IsSecretName(name) && !IsSecretValue(value) {
+				continue
+			}
+			secretCount++
+			switch secrets {
+			case SecretsStrip:
+				env.Variables[name] = ""
+			case SecretsEncrypt:
+				// the secret variables are already encrypted with the passphrase of the vault
+				if IsSecretValue(value) {
+					continue
+				}
+				encrypted, err := utils.Encrypt(key, value)
+				if err != nil {
+					return nil, err
+				}
+				env.Variables[name] = encryptedPrefix + encrypted
+			}
+		}
+	}
+
+	queryNames := map[uint]string{}
+	for _, query := range bundle.Queries {
+		queryNames[query.ID] = query.Name
+	}
+	examples := []Example{}
+	res := db.Db.Order("created_at").Find(&examples)
+	if res.Error != nil {
+		return nil, fmt.Errorf("while fetching all examples: %v", res.Error)
+	}
+	bundle.Examples = []BundleExample{}
+	for _, example := range examples {
+		if name, ok := queryNames[example.QueryID]; ok {
+			bundle.Examples = append(bundle.Examples, BundleExample{QueryName: name, Example: example})
+		}
+	}
+
+	bundle.Manifest.Counts = map[string]int{
+		"queries":      len(bundle.Queries),
+		"environments": len(bundle.Environments),
+		"examples":     len(bundle.Examples),
+		"secrets":      secretCount,
+	}
+	return &bundle, nil
+}
+
+// write the bundle as a tar.gz archive of json files, the manifest first
+func (b *Bundle) Write(w io.Writer) error {
+	gzipWriter := gzip.NewWriter(w)
+	tarWriter := tar.NewWriter(gzipWriter)
+	for _, file := range []struct {
+		name    string
+		content any
+	}{
+		{"manifest.json", b.Manifest},
+		{"queries.json", b.Queries},
+		{"environments.json", b.Environments},
+		{"examples.json", b.Examples},
+	} {
+		content, err := json.MarshalIndent(file.content, "", "  ")
+		if err != nil {
+			return fmt.Errorf("while encoding %s: %v", file.name, err)
+		}
+		err = tarWriter.WriteHeader(&tar.Header{
+			Name:    file.name,
+			Mode:    0600,
+			Size:    int64(len(content)),
+			ModTime: b.Manifest.CreatedAt,
+		})
+		if err != nil {
+			return fmt.Errorf("while writing %s: %v", file.name, err)
+		}
+		_, err = tarWriter.Write(content)
+		if err != nil {
+			return fmt.Errorf("while writing %s: %v", file.name, err)
+		}
+	}
+	err := tarWriter.Close()
+	if err != nil {
+		return fmt.Errorf("while writing the bundle: %v", err)
+	}
+	return gzipWriter.Close()
+}
+
+// read a bundle written by Write. The encrypted secrets stay encrypted, see DecryptSecrets
+func ReadBundle(r io.Reader) (*Bundle, error) {
+	gzipReader, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("the file is not a gourl bundle: %v", err)
+	}
+	tarReader := tar.NewReader(gzipReader)
+	bundle := Bundle{}
+	hasManifest := false
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("the file is not a gourl bundle: %v", err)
+		}
+		var target any
+		switch header.Name {
+		case "manifest.json":
+			target = &bundle.Manifest
+			hasManifest = true
+		case "queries.json":
+			target = &bundle.Queries
+		case "environments.json":
+			target = &bundle.Environments
+		case "examples.json":
+			target = &bundle.Examples
+		default:
+			// written by a newer version, the manifest tells if it matters
+			continue
+		}
+		err = json.NewDecoder(tarReader).Decode(target)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s in the bundle: %v", header.Name, err)
+		}
+	}
+	if !hasManifest || bundle.Manifest.Format != BundleFormat {
+		return nil, fmt.Errorf("the file is not a gourl bundle: no manifest found")
+	}
+	if bundle.Manifest.Version > BundleVersion {
+		return nil, fmt.Errorf("the bundle was written by a newer version of gourl (format version %d), please upgrade", bundle.Manifest.Version)
+	}
+	return &bundle, nil
+}
+
+// decrypt the secret variables of a bundle written with the encrypt mode
+func (b *Bundle) DecryptSecrets(passphrase string) error {
+	derivation := b.Manifest.KeyDerivation
+	if b.Manifest.Secrets != SecretsEncrypt || derivation == nil {
+		return nil
+	}
+	if derivation.Algorithm != "pbkdf2-sha256" {
+		return fmt.Errorf("the key derivation %s is not supported", derivation.Algorithm)
+	}
+	key, err := utils.DeriveKey(passphrase, derivation.Salt, derivation.Iterations)
+	if err != nil {
+		return err
+	}
+	for _, env := range b.Environments {
+		for name, value := range env.Variables {
+			if !strings.HasPrefix(value, encryptedPrefix) {
+				continue
+			}
+			decrypted, err := utils.Decrypt(key, strings.TrimPrefix(value, encryptedPrefix))
+			if err != nil {
+				return fmt.Errorf("while decrypting %s of the %s environment: %v", name, env.Name, err)
+			}
+			env.Variables[name] = decrypted
+		}
+	}
+	return nil
+}
+
+// the strategies to restore a query or an environment which already exists
+const (
+	RestoreSkip      = "skip"
+	RestoreOverwrite = "overwrite"
+	RestoreRename    = "rename"
+)
+
+// save the content of the bundle in the workspace. The queries and environments
+// already existing are skipped, overwritten or restored under another name.
+// Nothing is saved if any of them cannot be restored
+func (b *Bundle) Restore(strategy string) (*SyncReport, error) {
+	if strategy != RestoreSkip && strategy != RestoreOverwrite && strategy != RestoreRename {
+		return nil, fmt.Errorf("unknown strategy %s. It must be skip, overwrite or rename", strategy)
+	}
+	report := &SyncReport{}
+	var currentEnv *Environment
+	err := db.Db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		currentEnv, err = b.restore(tx, strategy, report)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if currentEnv != nil {
+		CurrentEnv = currentEnv
+	}
+	report.sort()
+	return report, nil
+}
+
+// restore the bundle in the transaction. It returns the current environment if it was overwritten
+func (b *Bundle) restore(tx *gorm.DB, strategy string, report *SyncReport) (*Environment, error) {
+	examplesByQuery := map[string][]Example{}
+	for _, example := range b.Examples {
+		examplesByQuery[example.QueryName] = append(examplesByQuery[example.QueryName], example.Example)
+	}
+	for _, query := range b.Queries {
+		bundleName := query.Name
+		existing, err := findByName[Query](tx, query.Name)
+		if err != nil {
+			return nil, fmt.Errorf("while fetching the query %s: %v", query.Name, err)
+		}
+		query.ID = 0
+		if existing != nil {
+			switch strategy {
+			case RestoreSkip:
+				report.Skipped = append(report.Skipped, fmt.Sprintf("queries/%s (already exists)", query.Name))
+				continue
+			case RestoreOverwrite:
+				// the examples of the overwritten query are replaced by the ones of the bundle
+				res := tx.Where("query_id = ?", existing.ID).Delete(&Example{})
+				if res.Error == nil {
+					res = tx.Delete(existing)
+				}
+				if res.Error != nil {
+					return nil, fmt.Errorf("while deleting the query %s: %v", query.Name, res.Error)
+				}
+			case RestoreRename:
+				query.Name, err = availableName(query.Name, func(name string) (bool, error) {
+					existing, err := findByName[Query](tx, name)
+					return existing != nil, err
+				})
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+		res := tx.Create(&query)
+		if res.Error != nil {
+			return nil, fmt.Errorf("while restoring the query %s: %v", query.Name, res.Error)
+		}
+		for _, example := range examplesByQuery[bundleName] {
+			example.ID = 0
+			example.QueryID = query.ID
+			res := tx.Create(&example)
+			if res.Error != nil {
+				return nil, fmt.Errorf("while restoring the examples of %s: %v", query.Name, res.Error)
+			}
+		}
+		report.addRestored("queries", bundleName, query.Name, existing != nil)
+	}
+
+	var currentEnv *Environment
+	for _, env := range b.Environments {
+		bundleName := env.Name
+		existing, err := findByName[Environment](tx, env.Name)
+		if err != nil {
+			return nil, fmt.Errorf("while fetching the environment %s: %v", env.Name, err)
+		}
+		env.ID = 0
+		env.Current = false
+		if existing != nil {
+			switch strategy {
+			case RestoreSkip:
+				report.Skipped = append(report.Skipped, fmt.Sprintf("environments/%s (already exists)", env.Name))
+				continue
+			case RestoreOverwrite:
+				// the environment stays the current one if it is
+				env.ID = existing.ID
+				env.Current = existing.Current
+				env.CreatedAt = existing.CreatedAt
+			case RestoreRename:
+				env.Name, err = availableName(env.Name, func(name string) (bool, error) {
+					existing, err := findByName[Environment](tx, name)
+					return existing != nil, err
+				})
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+		res := tx.Save(&env)
+		if res.Error != nil {
+			return nil, fmt.Errorf("while restoring the environment %s: %v", env.Name, res.Error)
+		}
+		if env.Current {
+			currentEnv = &env
+		}
+		report.addRestored("environments", bundleName, env.Name, existing != nil)
+	}
+	return currentEnv, nil
+}
+
+// return the record named name in the transaction, or nil if there is none
+func findByName[T Query | Environment](tx *gorm.DB, name string) (*T, error) {
+	records := []T{}
+	res := tx.Where("name = ?", name).Limit(1).Find(&records)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+	return &records[0], nil
+}
+
+func (r *SyncReport) addRestored(kind string, bundleName string, name string, existed bool) {
+	switch {
+	case name != bundleName:
+		r.Created = append(r.Created, fmt.Sprintf("%s/%s (renamed from %s)", kind, name, bundleName))
+	case existed:
+		r.Updated = append(r.Updated, kind+"/"+name)
+	default:
+		r.Created = append(r.Created, kind+"/"+name)
+	}
+}
+
+// return the first name not taken, ex: users/list (restored 2)
+func availableName(name string, exists func(string) (bool, error)) (string, error) {
+	for index := 1; ; index++ {
+		candidate := fmt.Sprintf("%s (restored)", name)
+		if index > 1 {
+			candidate = fmt.Sprintf("%s (restored %d)", name, index)
+		}
+		taken, err := exists(candidate)
+		if err != nil || !taken {
+			return candidate, err
+		}
+	}
+}
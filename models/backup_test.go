@@ -0,0 +1,93 @@
+package models
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+
+	"github.com/nakurai/gourl/db"
+	"github.com/nakurai/gourl/utils"
+)
+
+func TestBundleRoundTrip(t *testing.T) {
+	utils.DataDirPath = t.TempDir()
+	err := InitWorkspace()
+	if err != nil {
+		t.Errorf("%v\n", err)
+		return
+	}
+	defer func() { GlobalEnv = nil }()
+	query := Query{Name: "demo/users", Method: "GET", Url: "%{host}%/users", Tags: "users", Data: JSONMap{}, Header: JSONMap{"accept": "application/json"}, Cookie: JSONMap{}}
+	db.Db.Create(&query)
+	db.Db.Create(&Example{QueryID: query.ID, Status: "200 OK", StatusCode: 200, Header: JSONMap{}, Body: `[{"id":1}]`})
+	db.Db.Create(&Environment{Name: "staging", Parent: "default", Variables: JSONMap{"host": "staging.local", "api_token": "t0k3n"}})
+
+	bundle, err := NewBundle(SecretsEncrypt, "correct horse")
+	if err != nil {
+		t.Errorf("%v\n", err)
+		return
+	}
+	var archive bytes.Buffer
+	err = bundle.Write(&archive)
+	if err != nil {
+		t.Errorf("%v\n", err)
+		return
+	}
+
+	// restored in an empty workspace
+	utils.DataDirPath = t.TempDir()
+	err = InitWorkspace()
+	if err != nil {
+		t.Errorf("%v\n", err)
+		return
+	}
+	restored, err := ReadBundle(&archive)
+	if err != nil {
+		t.Errorf("%v\n", err)
+		return
+	}
+	err = restored.DecryptSecrets("correct horse")
+	if err != nil {
+		t.Errorf("%v\n", err)
+		return
+	}
+	report, err := restored.Restore(RestoreSkip)
+	if err != nil {
+		t.Errorf("%v\n", err)
+		return
+	}
+	if !slices.Equal(report.Created, []string{"environments/staging", "queries/demo/users"}) || !slices.Equal(report.Skipped, []string{"environments/default (already exists)"}) {
+		t.Errorf("unexpected report: %+v\n", report)
+	}
+
+	restoredQuery, err := GetQuery("demo/users")
+	if err != nil || restoredQuery == nil {
+		t.Errorf("the query should be restored (%v)\n", err)
+		return
+	}
+	if restoredQuery.Url != query.Url || restoredQuery.Tags != query.Tags || restoredQuery.Header["accept"] != "application/json" {
+		t.Errorf("the query should be restored as is, not %+v\n", restoredQuery)
+	}
+	examples, err := GetExamples(restoredQuery.ID)
+	if err != nil || len(examples) != 1 || examples[0].Body != `[{"id":1}]` {
+		t.Errorf("the example should be restored, not %v (%v)\n", examples, err)
+	}
+	staging, err := GetEnv("staging")
+	if err != nil || staging == nil {
+		t.Errorf("the environment should be restored (%v)\n", err)
+		return
+	}
+	if staging.Parent != "default" || staging.Variables["host"] != "staging.local" || staging.Variables["api_token"] != "t0k3n" {
+		t.Errorf("the environment should be restored with its secrets, not %+v\n", staging)
+	}
+
+	// restoring again renames everything
+	report, err = restored.Restore(RestoreRename)
+	if err != nil {
+		t.Errorf("%v\n", err)
+		return
+	}
+	if !slices.Contains(report.Created, "queries/demo/users (restored) (renamed from demo/users)") {
+		t.Errorf("the query should be restored under another name: %+v\n", report)
+	}
+}
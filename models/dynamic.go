@@ -0,0 +1,119 @@
+package models
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// a value generated for a built-in variable, kept so a run can be reproduced
+type GeneratedValue struct {
+	Name  string // ex: $randomInt(1,100)
+	Value string
+}
+
+// the values generated since the last call to ResetGeneratedValues
+var GeneratedValues = []GeneratedValue{}
+
+func ResetGeneratedValues() {
+	GeneratedValues = []GeneratedValue{}
+}
+
+// the built-in variables, generating a new value each time they are used.
+// They receive the arguments written between parenthesis, ex: $randomInt(1,100)
+var dynamicVariables = map[string]func(args []string) (string, error){
+	"$uuid": func(args []string) (string, error) {
+		return uuid.NewString(), nil
+	},
+	"$timestamp": func(args []string) (string, error) {
+		return strconv.FormatInt(time.Now().Unix(), 10), nil
+	},
+	"$isoDate": func(args []string) (string, error) {
+		return time.Now().UTC().Format(time.RFC3339), nil
+	},
+	"$randomInt": func(args []string) (string, error) {
+		min, max := int64(0), int64(1000)
+		if len(args) != 0 && len(args) != 2 {
+			return "", fmt.Errorf("$randomInt takes no argument or a min and a max, ex: $randomInt(1,100)")
+		}
+		if len(args) == 2 {
+			var err error
+			min, err = strconv.ParseInt(args[0], 10, 64)
+			if err != nil {
+				return "", fmt.Errorf("the min of $randomInt must be an integer, not %s", args[0])
+			}
+			max, err = strconv.ParseInt(args[1], 10, 64)
+			if err != nil {
+				return "", fmt.Errorf("the max of $randomInt must be an integer, not %s", args[1])
+			}
+			if max < min {
+				return "", fmt.Errorf("the max of $randomInt must be greater than its min")
+			}
+		}
+		// the size of the range can overflow an int64, ex: $randomInt(-1,9223372036854775807)
+		size := new(big.Int).Sub(big.NewInt(max), big.NewInt(min))
+		n, err := rand.Int(rand.Reader, size.Add(size, big.NewInt(1)))
+		if err != nil {
+			return "", err
+		}
+		return n.Add(n, big.NewInt(min)).String(), nil
+	},
+	"$randomEmail": func(args []string) (string, error) {
+		suffix, err := randomHex(4)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("user_%s@example.com", suffix), nil
+	},
+	"$nonce": func(args []string) (string, error) {
+		return randomHex(16)
+	},
+}
+
+// return the names of the built-in variables
+func DynamicVariableNames() []string {
+	return []string{"$uuid", "$timestamp", "$isoDate", "$randomInt(min,max)", "$randomEmail", "$nonce"}
+}
+
+// return true if the variable is a built-in one, ex: $uuid or $randomInt(1,100)
+func IsDynamicVariable(name string) bool {
+	return strings.HasPrefix(name, "$")
+}
+
+// generate the value of a built-in variable and record it in GeneratedValues
+func generateValue(name string) (string, error) {
+	functionName, args := name, []string{}
+	if open := strings.Index(name, "("); open != -1 && strings.HasSuffix(name, ")") {
+		functionName = name[:open]
+		if argList := strings.TrimSpace(name[open+1 : len(name)-1]); argList != "" {
+			for _, arg := range strings.Split(argList, ",") {
+				args = append(args, strings.TrimSpace(arg))
+			}
+		}
+	}
+	generate, ok := dynamicVariables[functionName]
+	if !ok {
+		return "", fmt.Errorf("unknown built-in variable %s. It must be one of: %s", functionName, strings.Join(DynamicVariableNames(), ", "))
+	}
+	value, err := generate(args)
+	if err != nil {
+		return "", err
+	}
+	GeneratedValues = append(GeneratedValues, GeneratedValue{Name: name, Value: value})
+	return value, nil
+}
+
+func randomHex(size int) (string, error) {
+	bytes := make([]byte, size)
+	_, err := rand.Read(bytes)
+	if err != nil {
+		return "", fmt.Errorf("while generating a random value: %v", err)
+	}
+	return hex.EncodeToString(bytes), nil
+}
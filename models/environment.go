@@ -3,6 +3,9 @@ package models
 import (
 	"errors"
 	"fmt"
+	"maps"
+	"os"
+	"slices"
 	"time"
 
 	"github.com/nakurai/gourl/db"
@@ -11,11 +14,18 @@ import (
 
 var CurrentEnv *Environment
 
+// the name of the environment holding the variables shared by all the environments
+const GlobalsEnvName = "globals"
+
+// the variables shared by all the environments, nil if none was ever defined
+var GlobalEnv *Environment
+
 type Environment struct {
 	ID          uint `gorm:"primaryKey"`
 	Name        string
 	Description string
 	Variables   JSONMap `gorm:"type:json"`
+	Parent      string // the name of the environment whose variables are inherited, if any
 	Current     bool
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
@@ -28,6 +38,9 @@ func (e Environment) String() string {
 	}else{
 		res = "  "+res
 	}
+	if e.Parent != "" {
+		res += " (inherits " + e.Parent + ")"
+	}
 	if e.Description != "" {
 		res += " - " + e.Description
 	}
@@ -35,6 +48,11 @@ func (e Environment) String() string {
 }
 
 func InitEnvironment() error {
+	globalEnv, err := GetEnv(GlobalsEnvName)
+	if err != nil {
+		return err
+	}
+	GlobalEnv = globalEnv
 
 	existDefault, err := GetEnv("default")
 	if err != nil {
@@ -47,6 +65,7 @@ func InitEnvironment() error {
 			Variables:   map[string]string{},
 			Current:     true,
 		}
+		CurrentEnv = &defaultEnv
 		return CreateEnv(&defaultEnv)
 	}
 
@@ -56,6 +75,12 @@ func InitEnvironment() error {
 	}
 	CurrentEnv = curEnv
 
+	if name := os.Getenv("GOURL_ENV"); name != "" {
+		err := UseEnv(name)
+		if err != nil {
+			return fmt.Errorf("while using the environment of GOURL_ENV: %v", err)
+		}
+	}
 	return nil
 
 }
@@ -107,3 +132,112 @@ func LoadEnv(name string) error{
 
 	return nil
 }
+
+// use the environment for this process only. Unlike LoadEnv, the current environment
+// saved in the database is not modified, so other terminals keep using it
+func UseEnv(name string) error {
+	env, err := GetEnv(name)
+	if err != nil {
+		return err
+	}
+	if env == nil || name == GlobalsEnvName {
+		return fmt.Errorf("no environment named %s exists", name)
+	}
+	CurrentEnv = env
+	return nil
+}
+
+// return all the environments, sorted by name
+func GetAllEnvs() ([]Environment, error) {
+	envs := []Environment{}
+	res := db.Db.Order("name").Find(&envs)
+	if res.Error != nil {
+		return nil, fmt.Errorf("while fetching all environments: %v", res.Error)
+	}
+	return envs, nil
+}
+
+// a variable and the name of the environment defining it
+type ResolvedVariable struct {
+	Name   string
+	Value  string
+	Origin string // the environment itself, one of its parents, or globals
+}
+
+// return the environment, its parents from the closest one, then the globals.
+// A missing parent ends the chain
+func (e *Environment) Chain() ([]*Environment, error) {
+	chain := []*Environment{e}
+	seen := map[string]bool{e.Name: true}
+	for env := e; env.Parent != "" && !seen[env.Parent]; {
+		parent, err := GetEnv(env.Parent)
+		if err != nil {
+			return nil, err
+		}
+		if parent == nil {
+			break
+		}
+		seen[parent.Name] = true
+		chain = append(chain, parent)
+		env = parent
+	}
+	if GlobalEnv != nil && !seen[GlobalsEnvName] {
+		chain = append(chain, GlobalEnv)
+	}
+	return chain, nil
+}
+
+// return the value of the variable, looked up in the environment, its parents then the globals
+func (e *Environment) Lookup(name string) (*ResolvedVariable, error) {
+	if value, ok := e.Variables[name]; ok {
+		return &ResolvedVariable{Name: name, Value: value, Origin: e.Name}, nil
+	}
+	chain, err := e.Chain()
+	if err != nil {
+		return nil, err
+	}
+	for _, env := range chain[1:] {
+		if value, ok := env.Variables[name]; ok {
+			return &ResolvedVariable{Name: name, Value: value, Origin: env.Name}, nil
+		}
+	}
+	return nil, nil
+}
+
+// return all the variables available in the environment, including the inherited
+// ones, sorted by name
+func (e *Environment) AllVariables() ([]ResolvedVariable, error) {
+	chain, err := e.Chain()
+	if err != nil {
+		return nil, err
+	}
+	byName := map[string]ResolvedVariable{}
+	for index := len(chain) - 1; index >= 0; index-- {
+		for name, value := range chain[index].Variables {
+			byName[name] = ResolvedVariable{Name: name, Value: value, Origin: chain[index].Name}
+		}
+	}
+	variables := []ResolvedVariable{}
+	for _, name := range slices.Sorted(maps.Keys(byName)) {
+		variables = append(variables, byName[name])
+	}
+	return variables, nil
+}
+
+// return the environment holding the global variables, it is created if needed
+func GetOrCreateGlobalEnv() (*Environment, error) {
+	if GlobalEnv != nil {
+		return GlobalEnv, nil
+	}
+	env := Environment{
+		Name:        GlobalsEnvName,
+		Description: "variables shared by all the environments",
+		Variables:   map[string]string{},
+	}
+	err := CreateEnv(&env)
+	if err != nil {
+		return nil, err
+	}
+	GlobalEnv = &env
+	return GlobalEnv, nil
+}
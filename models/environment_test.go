@@ -0,0 +1,55 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/nakurai/gourl/db"
+	"github.com/nakurai/gourl/utils"
+)
+
+func TestEnvironmentInheritance(t *testing.T) {
+	utils.DataDirPath = t.TempDir()
+	err := InitWorkspace()
+	if err != nil {
+		t.Errorf("%v\n", err)
+		return
+	}
+	defer func() { GlobalEnv = nil }()
+	for _, env := range []*Environment{
+		{Name: "base", Variables: JSONMap{"host": "base.local", "port": "80"}},
+		{Name: "dev", Parent: "base", Variables: JSONMap{"port": "8080"}},
+	} {
+		db.Db.Create(env)
+	}
+	globals, err := GetOrCreateGlobalEnv()
+	if err != nil {
+		t.Errorf("%v\n", err)
+		return
+	}
+	globals.Variables["region"] = "eu"
+	globals.Variables["host"] = "global.local"
+	db.Db.Save(globals)
+
+	err = UseEnv("dev")
+	if err != nil {
+		t.Errorf("%v\n", err)
+		return
+	}
+	res, err := ExpandVariable("%{host}%:%{port}%/%{region}%")
+	if err != nil || res != "base.local:8080/eu" {
+		t.Errorf("the value should be base.local:8080/eu not %s (%v)\n", res, err)
+	}
+
+	variables, err := CurrentEnv.AllVariables()
+	if err != nil {
+		t.Errorf("%v\n", err)
+		return
+	}
+	origins := map[string]string{}
+	for _, variable := range variables {
+		origins[variable.Name] = variable.Origin
+	}
+	if origins["host"] != "base" || origins["port"] != "dev" || origins["region"] != GlobalsEnvName {
+		t.Errorf("wrong origins of the variables: %v\n", origins)
+	}
+}
@@ -0,0 +1,181 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nakurai/gourl/db"
+	"github.com/nakurai/gourl/utils"
+)
+
+// a known-good response stored next to a saved query,
+// used for documentation and to compare live responses against
+type Example struct {
+	ID         uint `gorm:"primaryKey"`
+	QueryID    uint `gorm:"index"`
+	Status     string
+	StatusCode int
+	Header     JSONMap `gorm:"type:json"` // multiple values of the same header are joined with ", "
+	Body       string
+	CreatedAt  time.Time
+}
+
+// headers changing on every response, they are not compared
+var volatileHeaders = map[string]bool{
+	"age":           true,
+	"date":          true,
+	"etag":          true,
+	"expires":       true,
+	"last-modified": true,
+	"set-cookie":    true,
+}
+
+func NewExample(queryId uint, res *Response) Example {
+	header := JSONMap{}
+	for key, values := range res.Header {
+		header[strings.ToLower(key)] = strings.Join(values, ", ")
+	}
+	return Example{
+		QueryID:    queryId,
+		Status:     res.Status,
+		StatusCode: res.StatusCode,
+		Header:     header,
+		Body:       res.Body,
+	}
+}
+
+// return the example as a response, as if it was just received
+func (e Example) ToResponse() *Response {
+	header := http.Header{}
+	for key, value := range e.Header {
+		header.Set(key, value)
+	}
+	return &Response{
+		Status:     e.Status,
+		StatusCode: e.StatusCode,
+		Header:     header,
+		Body:       e.Body,
+		StartedAt:  e.CreatedAt,
+	}
+}
+
+// store the response as an example of the saved query
+func SaveExample(query *Query, res *Response) (*Example, error) {
+	if query.ID == 0 {
+		return nil, fmt.Errorf("only saved queries can have examples. Use the --save flag to save the query first")
+	}
+	example := NewExample(query.ID, res)
+	// the response may echo the secrets sent
+	example.Body = MaskSecrets(example.Body)
+	for key, value := range example.Header {
+		example.Header[key] = MaskSecrets(value)
+	}
+	dbRes := db.Db.Create(&example)
+	if dbRes.Error != nil {
+		return nil, fmt.Errorf("while saving the example of %s: %v", query.Name, dbRes.Error)
+	}
+	return &example, nil
+}
+
+// return all the examples of the query, the most recent first
+func GetExamples(queryId uint) ([]Example, error) {
+	examples := []Example{}
+	res := db.Db.Where("query_id = ?", queryId).Order("created_at desc, id desc").Find(&examples)
+	if res.Error != nil {
+		return nil, fmt.Errorf("while fetching the examples: %v", res.Error)
+	}
+	return examples, nil
+}
+
+// return the most recent example of the query, or nil if it has none
+func GetLatestExample(queryId uint) (*Example, error) {
+	examples, err := GetExamples(queryId)
+	if err != nil {
+		return nil, err
+	}
+	if len(examples) == 0 {
+		return nil, nil
+	}
+	return &examples[0], nil
+}
+
+// return the body, indented if it is JSON so it is easier to read and compare
+func (e Example) PrettyBody() string {
+	return prettyJson(e.Body)
+}
+
+// compare the live response with the example. The status, the non volatile headers
+// and the body are compared. It returns an empty list if they are the same,
+// otherwise the differences with a - for the example and a + for the live response
+func (e Example) Compare(res *Response) []string {
+	live := NewExample(e.QueryID, res)
+	diff := []string{}
+
+	if e.Status != live.Status {
+		diff = append(diff, "status:", "- "+e.Status, "+ "+live.Status)
+	}
+
+	headerDiff := []string{}
+	keys := map[string]bool{}
+	for key := range e.Header {
+		keys[key] = true
+	}
+	for key := range live.Header {
+		keys[key] = true
+	}
+	sortedHeaders := []string{}
+	for key := range keys {
+		sortedHeaders = append(sortedHeaders, key)
+	}
+	sort.Strings(sortedHeaders)
+	for _, key := range sortedHeaders {
+		if volatileHeaders[key] {
+			continue
+		}
+		expected, inExample := e.Header[key]
+		actual, inLive := live.Header[key]
+		if inExample && inLive && expected == actual {
+			continue
+		}
+		if inExample {
+			headerDiff = append(headerDiff, fmt.Sprintf("- %s: %s", key, expected))
+		}
+		if inLive {
+			headerDiff = append(headerDiff, fmt.Sprintf("+ %s: %s", key, actual))
+		}
+	}
+	if len(headerDiff) > 0 {
+		diff = append(diff, "headers:")
+		diff = append(diff, headerDiff...)
+	}
+
+	bodyDiff := utils.DiffLines(strings.Split(e.PrettyBody(), "\n"), strings.Split(live.PrettyBody(), "\n"))
+	if len(bodyDiff) > 0 {
+		diff = append(diff, "body:")
+		diff = append(diff, bodyDiff...)
+	}
+	return diff
+}
+
+// indent the JSON documents, with their keys sorted. Other strings are returned as is
+func prettyJson(s string) string {
+	var decoded any
+	if json.Unmarshal([]byte(s), &decoded) != nil {
+		return s
+	}
+	// decoding then encoding again sorts the keys of the objects
+	encoded, err := json.Marshal(decoded)
+	if err != nil {
+		return s
+	}
+	var res bytes.Buffer
+	if json.Indent(&res, encoded, "", "  ") != nil {
+		return s
+	}
+	return res.String()
+}
@@ -0,0 +1,462 @@
+package models
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/nakurai/gourl/db"
+	"github.com/nakurai/gourl/utils"
+	"gopkg.in/yaml.v3"
+)
+
+// the sub directories of a collection directory. A query named demo/users/list is
+// stored in queries/demo/users/list.yaml, an environment named staging in environments/staging.yaml
+const (
+	queriesDirName      = "queries"
+	environmentsDirName = "environments"
+	collectionFileExt   = ".yaml"
+)
+
+// the value written in the files instead of the value of a secret variable
+const secretPlaceholder = "<secret>"
+
+// the content of a query file. The name of the query is its path in the directory
+type queryFile struct {
+	Description string            `yaml:"description,omitempty"`
+	Tags        []string          `yaml:"tags,omitempty"`
+	Method      string            `yaml:"method"`
+	Url         string            `yaml:"url"`
+	Headers     map[string]string `yaml:"headers,omitempty"`
+	Cookies     map[string]string `yaml:"cookies,omitempty"`
+	Data        map[string]string `yaml:"data,omitempty"`
+	Json        bool              `yaml:"json,omitempty"`
+	Multipart   bool              `yaml:"multipart,omitempty"`
+	Body        string            `yaml:"body,omitempty"`
+}
+
+// the content of an environment file. The name of the environment is the name of the file
+type environmentFile struct {
+	Description string            `yaml:"description,omitempty"`
+	Parent      string            `yaml:"parent,omitempty"`
+	Variables   map[string]string `yaml:"variables"`
+}
+
+// what a synchronisation changed, by name
+type SyncReport struct {
+	Created []string
+	Updated []string
+	Deleted []string
+	Skipped []string // with the reason, ex: "staging (current environment)"
+}
+
+func (r *SyncReport) String() string {
+	res := ""
+	for _, change := range []struct {
+		label string
+		names []string
+	}{{"created", r.Created}, {"updated", r.Updated}, {"deleted", r.Deleted}, {"skipped", r.Skipped}} {
+		for _, name := range change.names {
+			res += fmt.Sprintf("%s: %s\n", change.label, name)
+		}
+	}
+	res += fmt.Sprintf("%d created, %d updated, %d deleted, %d skipped", len(r.Created), len(r.Updated), len(r.Deleted), len(r.Skipped))
+	return res
+}
+
+func encodeYaml(value any) ([]byte, error) {
+	var buffer bytes.Buffer
+	encoder := yaml.NewEncoder(&buffer)
+	encoder.SetIndent(2)
+	err := encoder.Encode(value)
+	if err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// return the query as the content of its file
+func (q Query) ToYaml() ([]byte, error) {
+	content, err := encodeYaml(queryFile{
+		Description: q.Description,
+		Tags:        q.TagList(),
+		Method:      q.Method,
+		Url:         q.Url,
+		Headers:     q.Header,
+		Cookies:     q.Cookie,
+		Data:        q.Data,
+		Json:        q.IsJson,
+		Multipart:   q.IsMultipart,
+		Body:        q.Body,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("while encoding the query %s: %v", q.Name, err)
+	}
+	return content, nil
+}
+
+// read the query from the content of its file
+func QueryFromYaml(name string, content []byte) (*Query, error) {
+	var file queryFile
+	err := yaml.Unmarshal(content, &file)
+	if err != nil {
+		return nil, fmt.Errorf("invalid query file for %s: %v", name, err)
+	}
+	if file.Method == "" || file.Url == "" {
+		return nil, fmt.Errorf("invalid query file for %s: the method and the url are mandatory", name)
+	}
+	query := Query{
+		Name:        name,
+		Description: file.Description,
+		Tags:        strings.Join(file.Tags, ","),
+		Method:      strings.ToUpper(file.Method),
+		Url:         file.Url,
+		Header:      file.Headers,
+		Cookie:      file.Cookies,
+		Data:        file.Data,
+		IsJson:      file.Json,
+		IsMultipart: file.Multipart,
+		Body:        file.Body,
+	}
+	for _, m := range []*JSONMap{&query.Header, &query.Cookie, &query.Data} {
+		if *m == nil {
+			*m = JSONMap{}
+		}
+	}
+	return &query, nil
+}
+
+// return true if the variable is not written in the files, because of its name or its
+// encrypted value
+func isSyncSecret(name string, value string) bool {
+	return utils.IsSecretName(name) || IsSecretValue(value)
+}
+
+// return the environment as the content of its file. The files are shared with git,
+// so the values of the secret variables are replaced by a placeholder
+func (e Environment) ToYaml() ([]byte, error) {
+	variables := map[string]string{}
+	for name, value := range e.Variables {
+		if isSyncSecret(name, value) {
+			value = secretPlaceholder
+		}
+		variables[name] = value
+	}
+	content, err := encodeYaml(environmentFile{Description: e.Description, Parent: e.Parent, Variables: variables})
+	if err != nil {
+		return nil, fmt.Errorf("while encoding the environment %s: %v", e.Name, err)
+	}
+	return content, nil
+}
+
+// read the environment from the content of its file
+func EnvironmentFromYaml(name string, content []byte) (*Environment, error) {
+	var file environmentFile
+	err := yaml.Unmarshal(content, &file)
+	if err != nil {
+		return nil, fmt.Errorf("invalid environment file for %s: %v", name, err)
+	}
+	if file.Variables == nil {
+		file.Variables = map[string]string{}
+	}
+	return &Environment{Name: name, Description: file.Description, Parent: file.Parent, Variables: file.Variables}, nil
+}
+
+// read all the files of the sub directory, by name. The name is the path of the
+// file without its extension, ex: demo/users/list
+func readCollectionFiles(dir string, subDir string) (map[string][]byte, error) {
+	files := map[string][]byte{}
+	root := filepath.Join(dir, subDir)
+	err := filepath.WalkDir(root, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && filePath == root {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if entry.IsDir() || filepath.Ext(filePath) != collectionFileExt {
+			return nil
+		}
+		relativePath, err := filepath.Rel(root, filePath)
+		if err != nil {
+			return err
+		}
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(strings.TrimSuffix(relativePath, collectionFileExt))] = content
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("while reading %s: %v", root, err)
+	}
+	return files, nil
+}
+
+// read all the queries and environments of the collection directory
+func ReadCollectionDir(dir string) ([]Query, []Environment, error) {
+	queryFiles, err := readCollectionFiles(dir, queriesDirName)
+	if err != nil {
+		return nil, nil, err
+	}
+	queries := []Query{}
+	for name, content := range queryFiles {
+		query, err := QueryFromYaml(name, content)
+		if err != nil {
+			return nil, nil, err
+		}
+		queries = append(queries, *query)
+	}
+
+	envFiles, err := readCollectionFiles(dir, environmentsDirName)
+	if err != nil {
+		return nil, nil, err
+	}
+	envs := []Environment{}
+	for name, content := range envFiles {
+		env, err := EnvironmentFromYaml(name, content)
+		if err != nil {
+			return nil, nil, err
+		}
+		envs = append(envs, *env)
+	}
+	return queries, envs, nil
+}
+
+// return an error if the name cannot be the path of a file inside the collection
+// directory, ex: ../../.bashrc or /etc/hosts
+func checkCollectionName(name string) error {
+	if name == "" || strings.HasPrefix(name, "/") || filepath.IsAbs(name) || strings.Contains(name, "\\") {
+		return fmt.Errorf("it must be a relative path")
+	}
+	for _, segment := range strings.Split(name, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return fmt.Errorf("%q is not allowed in a path", segment)
+		}
+	}
+	return nil
+}
+
+// write one file per query and per environment in the collection directory. Files are
+// only written if their content changed, and the files of the queries and environments
+// not provided are deleted, so the directory mirrors them. The queries and environments
+// whose name is not a valid path inside the directory are skipped
+func WriteCollectionDir(dir string, queries []Query, envs []Environment) (*SyncReport, error) {
+	report := &SyncReport{}
+	for _, subDir := range []string{queriesDirName, environmentsDirName} {
+		existing, err := readCollectionFiles(dir, subDir)
+		if err != nil {
+			return nil, err
+		}
+		contents := map[string][]byte{}
+		if subDir == queriesDirName {
+			for _, query := range queries {
+				if err := checkCollectionName(query.Name); err != nil {
+					report.Skipped = append(report.Skipped, fmt.Sprintf("%s/%s (invalid file name: %v)", subDir, query.Name, err))
+					continue
+				}
+				contents[query.Name], err = query.ToYaml()
+				if err != nil {
+					return nil, err
+				}
+			}
+		} else {
+			for _, env := range envs {
+				if err := checkCollectionName(env.Name); err != nil {
+					report.Skipped = append(report.Skipped, fmt.Sprintf("%s/%s (invalid file name: %v)", subDir, env.Name, err))
+					continue
+				}
+				contents[env.Name], err = env.ToYaml()
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		for name, content := range contents {
+			previous, exists := existing[name]
+			if exists && bytes.Equal(previous, content) {
+				continue
+			}
+			filePath := filepath.Join(dir, subDir, filepath.FromSlash(name)+collectionFileExt)
+			err := os.MkdirAll(filepath.Dir(filePath), os.ModePerm)
+			if err != nil {
+				return nil, fmt.Errorf("while creating the directory of %s: %v", filePath, err)
+			}
+			err = os.WriteFile(filePath, content, 0644)
+			if err != nil {
+				return nil, fmt.Errorf("while writing %s: %v", filePath, err)
+			}
+			if exists {
+				report.Updated = append(report.Updated, subDir+"/"+name)
+			} else {
+				report.Created = append(report.Created, subDir+"/"+name)
+			}
+		}
+		for name := range existing {
+			if _, ok := contents[name]; ok {
+				continue
+			}
+			filePath := filepath.Join(dir, subDir, filepath.FromSlash(name)+collectionFileExt)
+			err := os.Remove(filePath)
+			if err != nil {
+				return nil, fmt.Errorf("while deleting %s: %v", filePath, err)
+			}
+			removeEmptyDirs(filepath.Dir(filePath), filepath.Join(dir, subDir))
+			report.Deleted = append(report.Deleted, subDir+"/"+name)
+		}
+	}
+	report.sort()
+	return report, nil
+}
+
+// update the saved queries and environments with the files of the collection directory.
+// If prune is true, the queries and environments without a file are deleted, except the
+// current environment. The secret variables written as a placeholder keep their saved value
+func PullCollectionDir(dir string, prune bool) (*SyncReport, error) {
+	queries, envs, err := ReadCollectionDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	report := &SyncReport{}
+
+	existingQueries, err := GetAllQueries()
+	if err != nil {
+		return nil, err
+	}
+	byName := map[string]Query{}
+	for _, query := range existingQueries {
+		byName[query.Name] = query
+	}
+	for _, query := range queries {
+		existing, exists := byName[query.Name]
+		delete(byName, query.Name)
+		if exists {
+			before, err := existing.ToYaml()
+			if err != nil {
+				return nil, err
+			}
+			after, err := query.ToYaml()
+			if err != nil {
+				return nil, err
+			}
+			if bytes.Equal(before, after) {
+				continue
+			}
+			// the id is kept, so are the examples of the query
+			query.ID = existing.ID
+			query.CreatedAt = existing.CreatedAt
+		}
+		res := db.Db.Save(&query)
+		if res.Error != nil {
+			return nil, fmt.Errorf("while saving the query %s: %v", query.Name, res.Error)
+		}
+		if exists {
+			report.Updated = append(report.Updated, queriesDirName+"/"+query.Name)
+		} else {
+			report.Created = append(report.Created, queriesDirName+"/"+query.Name)
+		}
+	}
+	for name, query := range byName {
+		if !prune {
+			report.Skipped = append(report.Skipped, fmt.Sprintf("%s/%s (no file)", queriesDirName, name))
+			continue
+		}
+		err := DeleteQuery(&query)
+		if err != nil {
+			return nil, err
+		}
+		report.Deleted = append(report.Deleted, queriesDirName+"/"+name)
+	}
+
+	existingEnvs, err := GetAllEnvs()
+	if err != nil {
+		return nil, err
+	}
+	envsByName := map[string]Environment{}
+	for _, env := range existingEnvs {
+		envsByName[env.Name] = env
+	}
+	for _, env := range envs {
+		existing, exists := envsByName[env.Name]
+		delete(envsByName, env.Name)
+		for name, value := range env.Variables {
+			if value != secretPlaceholder {
+				continue
+			}
+			// a secret missing from this workspace is asked when a query uses it
+			if savedValue, ok := existing.Variables[name]; ok {
+				env.Variables[name] = savedValue
+			} else {
+				delete(env.Variables, name)
+			}
+		}
+		if exists {
+			before, err := existing.ToYaml()
+			if err != nil {
+				return nil, err
+			}
+			after, err := env.ToYaml()
+			if err != nil {
+				return nil, err
+			}
+			if bytes.Equal(before, after) {
+				continue
+			}
+			env.ID = existing.ID
+			env.Current = existing.Current
+			env.CreatedAt = existing.CreatedAt
+		}
+		res := db.Db.Save(&env)
+		if res.Error != nil {
+			return nil, fmt.Errorf("while saving the environment %s: %v", env.Name, res.Error)
+		}
+		if exists {
+			report.Updated = append(report.Updated, environmentsDirName+"/"+env.Name)
+		} else {
+			report.Created = append(report.Created, environmentsDirName+"/"+env.Name)
+		}
+		if env.Current {
+			CurrentEnv = &env
+		}
+	}
+	for name, env := range envsByName {
+		if !prune {
+			report.Skipped = append(report.Skipped, fmt.Sprintf("%s/%s (no file)", environmentsDirName, name))
+			continue
+		}
+		if env.Current {
+			report.Skipped = append(report.Skipped, fmt.Sprintf("%s/%s (current environment)", environmentsDirName, name))
+			continue
+		}
+		res := db.Db.Delete(&env)
+		if res.Error != nil {
+			return nil, fmt.Errorf("while deleting the environment %s: %v", name, res.Error)
+		}
+		report.Deleted = append(report.Deleted, environmentsDirName+"/"+name)
+	}
+	report.sort()
+	return report, nil
+}
+
+// remove the directory and its parents as long as they are empty, up to the root
+func removeEmptyDirs(dir string, root string) {
+	for dir != root && strings.HasPrefix(dir, root) {
+		if os.Remove(dir) != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+func (r *SyncReport) sort() {
+	for _, names := range [][]string{r.Created, r.Updated, r.Deleted, r.Skipped} {
+		slices.Sort(names)
+	}
+}
@@ -0,0 +1,161 @@
+package models
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/nakurai/gourl/db"
+	"github.com/nakurai/gourl/utils"
+)
+
+func TestCollectionDir(t *testing.T) {
+	dir := t.TempDir()
+	queries := []Query{
+		{Name: "demo/users/create", Method: "POST", Url: "%{host}%/users", Tags: "users,admin", IsJson: true, Data: JSONMap{"name": "john"}, Header: JSONMap{"x-api": "2"}, Cookie: JSONMap{}},
+		{Name: "health", Method: "GET", Url: "%{host}%/health", Body: "line 1\nline 2\n", Data: JSONMap{}, Header: JSONMap{}, Cookie: JSONMap{}},
+	}
+	envs := []Environment{{Name: "staging", Description: "the staging servers", Variables: JSONMap{"host": "https://staging.example.com"}}}
+
+	report, err := WriteCollectionDir(dir, queries, envs)
+	if err != nil {
+		t.Errorf("%v\n", err)
+		return
+	}
+	if len(report.Created) != 3 {
+		t.Errorf("3 files should be created, not %v\n", report.Created)
+	}
+	content, err := os.ReadFile(filepath.Join(dir, "queries", "demo", "users", "create.yaml"))
+	expected := `tags:
+  - users
+  - admin
+method: POST
+url: '%{host}%/users'
+headers:
+  x-api: "2"
+data:
+  name: john
+json: true
+`
+	if err != nil || string(content) != expected {
+		t.Errorf("the query file should be\n%s\nnot\n%s (%v)\n", expected, content, err)
+	}
+
+	readQueries, readEnvs, err := ReadCollectionDir(dir)
+	if err != nil {
+		t.Errorf("%v\n", err)
+		return
+	}
+	if len(readQueries) != 2 || len(readEnvs) != 1 || readEnvs[0].Variables["host"] != "https://staging.example.com" || readEnvs[0].Description != "the staging servers" {
+		t.Errorf("unexpected queries or environments: %v %v\n", readQueries, readEnvs)
+	}
+	for _, query := range readQueries {
+		if query.Name == "health" && query.Body != "line 1\nline 2\n" {
+			t.Errorf("the body should be kept as is, not %q\n", query.Body)
+		}
+		if query.Name == "demo/users/create" && (query.Tags != "users,admin" || !query.IsJson || query.Data["name"] != "john") {
+			t.Errorf("unexpected query: %+v\n", query)
+		}
+	}
+
+	// nothing changed, except the deleted query
+	report, err = WriteCollectionDir(dir, queries[1:], envs)
+	if err != nil {
+		t.Errorf("%v\n", err)
+		return
+	}
+	if len(report.Created) != 0 || len(report.Updated) != 0 || len(report.Deleted) != 1 || report.Deleted[0] != "queries/demo/users/create" {
+		t.Errorf("only the file of the deleted query should be removed: %+v\n", report)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "queries", "demo")); !os.IsNotExist(err) {
+		t.Errorf("the empty directories should be removed\n")
+	}
+}
+
+func TestCollectionDirSafety(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "collection")
+	queries := []Query{
+		{Name: "../../escaped", Method: "GET", Url: "https://example.com", Data: JSONMap{}, Header: JSONMap{}, Cookie: JSONMap{}},
+		{Name: "/etc/escaped", Method: "GET", Url: "https://example.com", Data: JSONMap{}, Header: JSONMap{}, Cookie: JSONMap{}},
+	}
+	envs := []Environment{
+		{Name: "..", Variables: JSONMap{}},
+		{Name: "staging", Variables: JSONMap{"host": "staging.local", "api_token": "t0k3n", "key": secretPrefix + "c2FsdA==:c2VhbGVk"}},
+	}
+	report, err := WriteCollectionDir(dir, queries, envs)
+	if err != nil {
+		t.Errorf("%v\n", err)
+		return
+	}
+	if len(report.Created) != 1 || len(report.Skipped) != 3 {
+		t.Errorf("only the staging environment should be written: %+v\n", report)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(dir), "escaped.yaml")); !os.IsNotExist(err) {
+		t.Errorf("no file should be written outside of the directory\n")
+	}
+	content, err := os.ReadFile(filepath.Join(dir, "environments", "staging.yaml"))
+	if err != nil {
+		t.Errorf("%v\n", err)
+		return
+	}
+	if strings.Contains(string(content), "t0k3n") || strings.Contains(string(content), secretPrefix) || strings.Count(string(content), secretPlaceholder) != 2 {
+		t.Errorf("the secret values should be replaced by a placeholder:\n%s\n", content)
+	}
+}
+
+func TestPullCollectionDir(t *testing.T) {
+	utils.DataDirPath = t.TempDir()
+	err := InitWorkspace()
+	if err != nil {
+		t.Errorf("%v\n", err)
+		return
+	}
+	defer func() { GlobalEnv = nil }()
+	db.Db.Create(&Environment{Name: "staging", Variables: JSONMap{"host": "old.local", "api_token": "t0k3n"}})
+	db.Db.Create(&Query{Name: "local", Method: "GET", Url: "https://example.com", Data: JSONMap{}, Header: JSONMap{}, Cookie: JSONMap{}})
+
+	dir := t.TempDir()
+	_, err = WriteCollectionDir(dir, []Query{
+		{Name: "health", Method: "GET", Url: "%{host}%/health", Data: JSONMap{}, Header: JSONMap{}, Cookie: JSONMap{}},
+	}, []Environment{
+		{Name: "staging", Variables: JSONMap{"host": "new.local", "api_token": "other", "db_password": "pa55"}},
+	})
+	if err != nil {
+		t.Errorf("%v\n", err)
+		return
+	}
+
+	report, err := PullCollectionDir(dir, false)
+	if err != nil {
+		t.Errorf("%v\n", err)
+		return
+	}
+	if len(report.Deleted) != 0 || !slices.Contains(report.Skipped, "queries/local (no file)") {
+		t.Errorf("nothing should be deleted without prune: %+v\n", report)
+	}
+	staging, err := GetEnv("staging")
+	if err != nil {
+		t.Errorf("%v\n", err)
+		return
+	}
+	if staging.Variables["host"] != "new.local" || staging.Variables["api_token"] != "t0k3n" {
+		t.Errorf("the saved secrets should be kept: %v\n", staging.Variables)
+	}
+	if _, ok := staging.Variables["db_password"]; ok {
+		t.Errorf("the placeholder of a secret should not be saved: %v\n", staging.Variables)
+	}
+
+	report, err = PullCollectionDir(dir, true)
+	if err != nil {
+		t.Errorf("%v\n", err)
+		return
+	}
+	if !slices.Contains(report.Deleted, "queries/local") || !slices.Contains(report.Skipped, "environments/default (current environment)") {
+		t.Errorf("the queries without a file should be deleted with prune: %+v\n", report)
+	}
+	if len(report.Updated) != 0 {
+		t.Errorf("the secrets kept should not count as an update: %+v\n", report)
+	}
+}
@@ -0,0 +1,289 @@
+package models
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// a function usable in the variables, ex: %{base64(concat(user, ":", pass))}%
+type templateFunction struct {
+	Usage   string // ex: base64(value)
+	MinArgs int
+	MaxArgs int // -1 if the number of arguments is not limited
+	Call    func(args []string) (string, error)
+}
+
+var templateFunctions = map[string]templateFunction{
+	"concat": {"concat(value, ...)", 1, -1, func(args []string) (string, error) {
+		return strings.Join(args, ""), nil
+	}},
+	"lower": {"lower(value)", 1, 1, func(args []string) (string, error) {
+		return strings.ToLower(args[0]), nil
+	}},
+	"upper": {"upper(value)", 1, 1, func(args []string) (string, error) {
+		return strings.ToUpper(args[0]), nil
+	}},
+	"trim": {"trim(value)", 1, 1, func(args []string) (string, error) {
+		return strings.TrimSpace(args[0]), nil
+	}},
+	"base64": {"base64(value)", 1, 1, func(args []string) (string, error) {
+		return base64.StdEncoding.EncodeToString([]byte(args[0])), nil
+	}},
+	"base64Decode": {"base64Decode(value)", 1, 1, func(args []string) (string, error) {
+		decoded, err := base64.StdEncoding.DecodeString(args[0])
+		if err != nil {
+			return "", fmt.Errorf("base64Decode: %v", err)
+		}
+		return string(decoded), nil
+	}},
+	"urlencode": {"urlencode(value)", 1, 1, func(args []string) (string, error) {
+		return url.QueryEscape(args[0]), nil
+	}},
+	"urldecode": {"urldecode(value)", 1, 1, func(args []string) (string, error) {
+		decoded, err := url.QueryUnescape(args[0])
+		if err != nil {
+			return "", fmt.Errorf("urldecode: %v", err)
+		}
+		return decoded, nil
+	}},
+	"sha256": {"sha256(value)", 1, 1, func(args []string) (string, error) {
+		sum := sha256.Sum256([]byte(args[0]))
+		return hex.EncodeToString(sum[:]), nil
+	}},
+	"hmacSha256": {"hmacSha256(key, value)", 2, 2, func(args []string) (string, error) {
+		return hex.EncodeToString(hmacSha256(args[0], args[1])), nil
+	}},
+	"hmacSha256Base64": {"hmacSha256Base64(key, value)", 2, 2, func(args []string) (string, error) {
+		return base64.StdEncoding.EncodeToString(hmacSha256(args[0], args[1])), nil
+	}},
+	"now": {"now()", 0, 0, func(args []string) (string, error) {
+		return time.Now().UTC().Format(time.RFC3339), nil
+	}},
+	"dateAdd": {"dateAdd(date, duration)", 2, 2, func(args []string) (string, error) {
+		date, err := parseDate(args[0])
+		if err != nil {
+			return "", err
+		}
+		duration, err := parseDuration(args[1])
+		if err != nil {
+			return "", err
+		}
+		return date.Add(duration).Format(time.RFC3339), nil
+	}},
+	"dateFormat": {"dateFormat(date, layout)", 2, 2, func(args []string) (string, error) {
+		date, err := parseDate(args[0])
+		if err != nil {
+			return "", err
+		}
+		switch args[1] {
+		case "unix":
+			return strconv.FormatInt(date.Unix(), 10), nil
+		case "unixMilli":
+			return strconv.FormatInt(date.UnixMilli(), 10), nil
+		default:
+			return date.Format(args[1]), nil
+		}
+	}},
+}
+
+// return the usage of all the functions, sorted by name
+func TemplateFunctionUsages() []string {
+	usages := []string{}
+	for _, function := range templateFunctions {
+		usages = append(usages, function.Usage)
+	}
+	slices.Sort(usages)
+	return usages
+}
+
+func hmacSha256(key string, value string) []byte {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(value))
+	return mac.Sum(nil)
+}
+
+// parse a date written in RFC 3339, ex: 2024-05-01T12:00:00Z, or a unix timestamp
+func parseDate(value string) (time.Time, error) {
+	if timestamp, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(timestamp, 0).UTC(), nil
+	}
+	date, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return date, fmt.Errorf("invalid date %s, it must be in the RFC 3339 format, ex: 2024-05-01T12:00:00Z", value)
+	}
+	return date, nil
+}
+
+// parse a duration like 1h30m or -15m, and the days like 7d
+func parseDuration(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if count, err := strconv.Atoi(days); err == nil {
+			return time.Duration(count) * 24 * time.Hour, nil
+		}
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %s, ex: 1h30m, -15m or 7d", value)
+	}
+	return duration, nil
+}
+
+var expressionRegex = regexp.MustCompile(`^[a-zA-Z]\w*\s*\(`)
+var numberRegex = regexp.MustCompile(`^-?\d+(\.\d+)?$`)
+
+// return true if the reference is a call to a function, ex: base64(token)
+func IsExpression(name string) bool {
+	return expressionRegex.MatchString(name)
+}
+
+// a token of an expression: a string, a parenthesis, a comma or a word
+type expressionToken struct {
+	Text     string
+	IsString bool
+}
+
+func tokenizeExpression(expression string) ([]expressionToken, error) {
+	tokens := []expressionToken{}
+	for index := 0; index < len(expression); {
+		char := expression[index]
+		switch {
+		case char == ' ' || char == '\t':
+			index++
+		case char == '(' || char == ')' || char == ',':
+			tokens = append(tokens, expressionToken{Text: string(char)})
+			index++
+		case char == '"' || char == '\'':
+			text := ""
+			end := index + 1
+			for ; end < len(expression) && expression[end] != char; end++ {
+				if expression[end] == '\\' && end+1 < len(expression) {
+					end++
+				}
+				text += expression[end : end+1]
+			}
+			if end >= len(expression) {
+				return nil, fmt.Errorf("unterminated string in %s", expression)
+			}
+			tokens = append(tokens, expressionToken{Text: text, IsString: true})
+			index = end + 1
+		default:
+			end := index
+			for end < len(expression) && !strings.ContainsRune(" \t(),\"'", rune(expression[end])) {
+				end++
+			}
+			tokens = append(tokens, expressionToken{Text: expression[index:end]})
+			index = end
+		}
+	}
+	return tokens, nil
+}
+
+// evaluate an expression like base64(concat(user, ":", pass)). The words are the
+// names of variables, the numbers and the quoted strings are literals
+func evaluateExpression(expression string, chain []string, body *string) (string, error) {
+	tokens, err := tokenizeExpression(expression)
+	if err != nil {
+		return "", err
+	}
+	parser := expressionParser{tokens: tokens, chain: chain, body: body}
+	value, err := parser.evaluate()
+	if err != nil {
+		return "", err
+	}
+	if parser.position < len(tokens) {
+		return "", fmt.Errorf("unexpected %s in %s", tokens[parser.position].Text, expression)
+	}
+	return value, nil
+}
+
+type expressionParser struct {
+	tokens   []expressionToken
+	position int
+	chain    []string
+	body     *string // the body of the request, used by $body
+}
+
+func (p *expressionParser) next() (expressionToken, bool) {
+	if p.position >= len(p.tokens) {
+		return expressionToken{}, false
+	}
+	token := p.tokens[p.position]
+	p.position++
+	return token, true
+}
+
+func (p *expressionParser) peek(text string) bool {
+	return p.position < len(p.tokens) && !p.tokens[p.position].IsString && p.tokens[p.position].Text == text
+}
+
+func (p *expressionParser) evaluate() (string, error) {
+	token, ok := p.next()
+	if !ok {
+		return "", fmt.Errorf("the expression ends unexpectedly")
+	}
+	if token.IsString {
+		return token.Text, nil
+	}
+	if token.Text == "(" || token.Text == ")" || token.Text == "," {
+		return "", fmt.Errorf("unexpected %s in the expression", token.Text)
+	}
+	if !p.peek("(") {
+		if numberRegex.MatchString(token.Text) {
+			return token.Text, nil
+		}
+		return resolveReference(token.Text, p.chain, p.body)
+	}
+
+	// the arguments of a built-in variable are part of its name, ex: $randomInt(1,100)
+	if IsDynamicVariable(token.Text) {
+		name := token.Text
+		for {
+			part, ok := p.next()
+			if !ok {
+				return "", fmt.Errorf("missing ) after the arguments of %s", token.Text)
+			}
+			name += part.Text
+			if part.Text == ")" && !part.IsString {
+				return resolveReference(name, p.chain, p.body)
+			}
+		}
+	}
+
+	function, ok := templateFunctions[token.Text]
+	if !ok {
+		return "", fmt.Errorf("unknown function %s. It must be one of: %s", token.Text, strings.Join(TemplateFunctionUsages(), ", "))
+	}
+	p.next()
+	args := []string{}
+	if p.peek(")") {
+		p.next()
+	} else {
+		for {
+			arg, err := p.evaluate()
+			if err != nil {
+				return "", err
+			}
+			args = append(args, arg)
+			separator, ok := p.next()
+			if !ok || separator.IsString || (separator.Text != "," && separator.Text != ")") {
+				return "", fmt.Errorf("missing ) after the arguments of %s", token.Text)
+			}
+			if separator.Text == ")" {
+				break
+			}
+		}
+	}
+	if len(args) < function.MinArgs || (function.MaxArgs != -1 && len(args) > function.MaxArgs) {
+		return "", fmt.Errorf("wrong number of arguments, the usage is %s", function.Usage)
+	}
+	return function.Call(args)
+}
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"testing"
+)
+
+func TestExpandFunction(t *testing.T) {
+	CurrentEnv = &Environment{Variables: map[string]string{
+		"user":   "alice",
+		"pass":   "s3cr3t",
+		"q":      "a b&c",
+		"secret": "key",
+	}}
+	for template, expected := range map[string]string{
+		`%{base64(concat(user, ":", pass))}%`: "YWxpY2U6czNjcjN0",
+		`%{urlencode(q)}%`:                    "a+b%26c",
+		`%{sha256("")}%`:                      "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		`%{hmacSha256(secret, "The quick brown fox jumps over the lazy dog")}%`: "f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8",
+		`%{dateAdd("2024-05-01T12:00:00Z", "7d")}%`:                             "2024-05-08T12:00:00Z",
+		`%{dateFormat(dateAdd(1714564800, "-1h"), "unix")}%`:                    "1714561200",
+		`%{upper(missing):-none}%`:                                              "none",
+		`%{concat(user, ":-", $randomInt(3,3))}%`:                               "alice:-3",
+	} {
+		res, err := ExpandVariable(template)
+		if err != nil {
+			t.Errorf("%s: %v\n", template, err)
+			continue
+		}
+		if res != expected {
+			t.Errorf("%s should be expanded to %s not %s\n", template, expected, res)
+		}
+	}
+
+	for _, template := range []string{
+		`%{unknown(user)}%`,
+		`%{base64(user, pass)}%`,
+		`%{base64(user}%`,
+		`%{concat(user, "a)}%`,
+		`%{base64($body)}%`,
+	} {
+		if _, err := ExpandVariable(template); err == nil {
+			t.Errorf("%s should fail\n", template)
+		}
+	}
+}
+
+func TestBodyVariable(t *testing.T) {
+	CurrentEnv = &Environment{Variables: map[string]string{"secret": "key"}}
+	query := Query{
+		Method: "POST",
+		Url:    "https://example.com/hooks",
+		Body:   `{"event":"ping"}`,
+		Header: map[string]string{"x-signature": "%{hmacSha256(secret, $body)}%", "x-length": "%{$body}%"},
+	}
+	req, err := query.NewRequest()
+	if err != nil {
+		t.Errorf("%v\n", err)
+		return
+	}
+	if req.Header.Get("x-length") != `{"event":"ping"}` || len(req.Header.Get("x-signature")) != 64 {
+		t.Errorf("the headers should use the body of the request, not %v\n", req.Header)
+	}
+
+	// the body is only known while its request is built
+	if _, err := ExpandVariable("%{$body}%"); err == nil {
+		t.Errorf("$body should not be the body of the previous request\n")
+	}
+}
@@ -6,9 +6,12 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"mime/multipart"
 	"net/http"
 	"net/url"
-	"regexp"
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
@@ -19,45 +22,202 @@ import (
 var client = &http.Client{}
 
 type Query struct {
-	ID        uint    `gorm:"primaryKey"`
-	Data      JSONMap `gorm:"type:json"`
-	Header    JSONMap `gorm:"type:json"`
-	Cookie    JSONMap `gorm:"type:json"`
-	IsJson    bool
-	Method    string
-	Name      string // if the query is a saved query. For example: demo/post/message
-	Url       string
-	CreatedAt time.Time
-	UpdatedAt time.Time
-}
-
-var varRegex, _ = regexp.Compile(`%{\w+}%`)
-
-func ExpandVariable(v string) (string, error){
-	varMatch := varRegex.FindAllString(v, -1)
-	if len(varMatch) > 0{
-		newValue := strings.Clone(v)
-		for _, match := range varMatch{
-			varName := strings.Replace(match, "%{", "", -1)
-			varName = strings.Replace(varName, "}%", "", -1)
-			varValue, ok := CurrentEnv.Variables[varName]
-			if !ok{
-				return "", fmt.Errorf("unknown variable %s", varName)
-			}
-			newValue = strings.Replace(newValue, match, varValue, -1)
+	ID          uint    `gorm:"primaryKey"`
+	Data        JSONMap `gorm:"type:json"`
+	Header      JSONMap `gorm:"type:json"`
+	Cookie      JSONMap `gorm:"type:json"`
+	IsJson      bool
+	IsMultipart bool   // the data is sent as a multipart form. Values starting with @ are paths of files to upload, @@ escapes a literal @
+	Body        string // raw body sent as is. When set, the data is sent in the url's query string
+	Method      string
+	Name        string // if the query is a saved query. For example: demo/post/message
+	Url         string
+	Description string // free text explaining what the query is for
+	Tags        string // comma separated list of tags. For example: users,admin
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// return the tags of the query as a list
+func (q Query) TagList() []string {
+	tags := []string{}
+	for _, tag := range strings.Split(q.Tags, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// return true if the query has the tag, ignoring the case
+func (q Query) HasTag(tag string) bool {
+	for _, existingTag := range q.TagList() {
+		if strings.EqualFold(existingTag, tag) {
+			return true
 		}
-		return newValue, nil
-	} else{
-		return v, nil
 	}
+	return false
+}
+
+// values used for this execution only, on top of the variables of the current environment
+var TemporaryVariables = map[string]string{}
+
+// return the value of the variable in the current environment, its parents or the globals
+func environmentValue(name string) (string, bool, error) {
+	if value, ok := TemporaryVariables[name]; ok {
+		return value, true, nil
+	}
+	variable, err := CurrentEnv.Lookup(name)
+	if err != nil || variable == nil {
+		return "", false, err
+	}
+	return variable.Value, true, nil
 }
 
+// returned when a variable has no value, so its default value can be used instead
+type missingVariableError struct {
+	message string
+}
+
+func (e *missingVariableError) Error() string {
+	return e.message
+}
+
+// return the value of the variable, read from its source. body is the body of the
+// request, used by $body. It is nil until the body is known
+func lookupVariable(varName string, body *string) (string, error) {
+	switch {
+	case varName == "$body":
+		if body == nil {
+			return "", fmt.Errorf("$body can only be used in the headers and cookies, once the body is known")
+		}
+		return *body, nil
+	case IsDynamicVariable(varName):
+		return generateValue(varName)
+	case strings.HasPrefix(varName, "env:"):
+		name := strings.TrimPrefix(varName, "env:")
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			return "", &missingVariableError{fmt.Sprintf("the environment variable %s used by %%{%s}%% is not set", name, varName)}
+		}
+		return value, nil
+	case strings.HasPrefix(varName, "file:"):
+		filePath := strings.TrimPrefix(varName, "file:")
+		content, err := os.ReadFile(filePath)
+		if errors.Is(err, os.ErrNotExist) {
+			return "", &missingVariableError{fmt.Sprintf("the file %s used by %%{%s}%% does not exist", filePath, varName)}
+		}
+		if err != nil {
+			return "", fmt.Errorf("while reading the file used by %%{%s}%%: %v", varName, err)
+		}
+		// the files holding a secret usually end with a new line which is not part of it
+		return strings.TrimSuffix(strings.TrimSuffix(string(content), "\n"), "\r"), nil
+	}
+	varValue, ok, err := environmentValue(varName)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", &missingVariableError{fmt.Sprintf("unknown variable %s", varName)}
+	}
+	// the secrets are only decrypted when they are used
+	if IsSecretValue(varValue) {
+		value, err := DecryptSecret(varValue)
+		if err != nil {
+			return "", fmt.Errorf("while decrypting the secret %s: %v", varName, err)
+		}
+		return value, nil
+	}
+	return varValue, nil
+}
+
+// the maximum number of variables referencing each other, ex: api_url -> host -> domain
+const maxExpansionDepth = 10
+
+// replace the variables of v by their value, read from the current environment
+// or from their source. The built-in variables get a new value each time they are used.
+// The values and the names of the variables can themselves hold variables, ex:
+// %{token_%{env}%}%, they are expanded recursively
+func ExpandVariable(v string) (string, error) {
+	return expandTemplate(v, []string{}, nil)
+}
+
+// chain holds the variables being expanded, to detect the cycles and report where an error comes from.
+// body is the body of the request used by $body, once it is known
+func expandTemplate(v string, chain []string, body *string) (string, error) {
+	res := ""
+	for _, part := range SplitVariables(v) {
+		if !part.IsVariable {
+			res += part.Text
+			continue
+		}
+		value, err := resolveReference(part.Text, chain, body)
+		if err != nil {
+			return "", err
+		}
+		res += value
+	}
+	return res, nil
+}
+
+// return the value of the reference: the value of its variable, or its default value
+// if the variable is missing
+func resolveReference(expression string, chain []string, body *string) (string, error) {
+	reference := ParseReference(expression)
+	name, err := expandTemplate(reference.Name, chain, body)
+	if err != nil {
+		return "", err
+	}
+	var value string
+	if IsExpression(name) {
+		value, err = evaluateExpression(name, chain, body)
+	} else {
+		value, err = lookupVariable(name, body)
+	}
+	var missingErr *missingVariableError
+	if errors.As(err, &missingErr) {
+		switch {
+		case reference.HasDefault:
+			return expandTemplate(reference.Default, chain, body)
+		case reference.Required && reference.Message != "":
+			err = fmt.Errorf("%s: %s", name, reference.Message)
+		case reference.Required:
+			err = fmt.Errorf("%s: the variable is required", name)
+		}
+	}
+	if err != nil {
+		if len(chain) > 0 {
+			return "", fmt.Errorf("%v (while expanding %s)", err, strings.Join(append(chain, name), " -> "))
+		}
+		return "", err
+	}
+
+	// only the values of the environment are templates, not the generated values or the
+	// content of the environment variables and files
+	if IsDynamicVariable(name) || strings.ContainsAny(name, ":(") {
+		return value, nil
+	}
+	chain = append(chain[:len(chain):len(chain)], name)
+	if slices.Contains(chain[:len(chain)-1], name) {
+		return "", fmt.Errorf("the variable %s references itself: %s", name, strings.Join(chain, " -> "))
+	}
+	if len(chain) > maxExpansionDepth {
+		return "", fmt.Errorf("more than %d variables reference each other: %s", maxExpansionDepth, strings.Join(chain, " -> "))
+	}
+	return expandTemplate(value, chain, body)
+}
 
 func ExpandMapVariable(d map[string]string)(map[string]string, error){
+	return expandMap(d, nil)
+}
+
+// replace the variables of the values of d, body is the body of the request used by $body
+func expandMap(d map[string]string, body *string)(map[string]string, error){
 	res := map[string]string{}
 	
 	for key, value := range d{
-		expandedValue, err := ExpandVariable(value)
+		expandedValue, err := expandTemplate(value, []string{}, body)
 		if err != nil{
 			return res, err
 		}
@@ -68,63 +228,132 @@ func ExpandMapVariable(d map[string]string)(map[string]string, error){
 	return res, nil
 }
 
+// return true if the data of the query is sent in the body,
+// otherwise it is sent in the url's query string
+func (q Query) HasBody() bool {
+	method := strings.ToUpper(q.Method)
+	return method == http.MethodPost || method == http.MethodPut || method == http.MethodPatch
+}
+
+// the response received after sending a query
+type Response struct {
+	Url        string // the url after the variables were expanded
+	Proto      string // ex: HTTP/1.1
+	Status     string // ex: 200 OK
+	StatusCode int
+	Header     http.Header
+	Body       string
+	StartedAt  time.Time
+	Duration   time.Duration // time between sending the request and reading the whole body
+}
+
 // build the http query from the query's content.
 // if verbose is true, it will display headers, urls, data sent and the body
 // if verbose is false, it will only display the bpdy as a string
 func (q *Query) Send(verbose bool) (string, error) {
+	res, err := q.Do()
+	if err != nil {
+		return "", err
+	}
+	return res.Format(verbose)
+}
+
+// build the http query from the query's content, send it and read the response
+func (q *Query) Do() (*Response, error) {
+	req, err := q.NewRequest()
+	if err != nil {
+		return nil, err
+	}
+	return DoRequest(req)
+}
+
+// build the http request from the query's content, with all its variables expanded
+func (q *Query) NewRequest() (*http.Request, error) {
 	// just in case
 	q.Method = strings.ToUpper(q.Method)
 	var body io.Reader
-	urlToUse, err := ExpandVariable(q.Url) 
-	if err != nil {
-		return "", err
+	// the data is sent in the url's query string, GetQueryUrl expands the url itself
+	// so the built-in variables are only generated once
+	dataInUrl := len(q.Data) > 0 && !(q.HasBody() && q.Body == "")
+	urlToUse := ""
+	var err error
+	if !dataInUrl {
+		urlToUse, err = ExpandVariable(q.Url)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	contentType := ""
+	if q.Body != "" {
+		expandedBody, err := ExpandVariable(q.Body)
+		if err != nil {
+			return nil, err
+		}
+		body = strings.NewReader(expandedBody)
 	}
-	isPost := q.Method == http.MethodConnect
-	isPut := q.Method == http.MethodPut
-	isPatch := q.Method == http.MethodPatch
 
 	if len(q.Data) > 0 {
-		var err error
-		if isPost || isPut || isPatch {
+		if !dataInUrl {
 			// this required to add the parameters in a body
-			if q.IsJson {
+			if q.IsMultipart {
+				body, contentType, err = q.GetMultipartParam()
+				if err != nil {
+					return nil, err
+				}
+			} else if q.IsJson {
 				body, err = q.GetJsonParam()
 				if err != nil {
-					return "", err
+					return nil, err
 				}
 			} else {
 				body, err = q.GetFormParam()
 				if err != nil {
-					return "", err
+					return nil, err
 				}
 			}
 		} else {
 			// otherwise the parameters are part of the URL
 			urlToUse, err = q.GetQueryUrl()
 			if err != nil {
-				return "", err
+				return nil, err
 			}
 		}
 	}
 
+	// the headers and cookies can use the body, ex: %{hmacSha256(secret, $body)}%
+	sentBody := ""
+	if body != nil {
+		content, err := io.ReadAll(body)
+		if err != nil {
+			return nil, err
+		}
+		sentBody = string(content)
+		body = bytes.NewReader(content)
+	}
+
 	req, err := http.NewRequest(q.Method, urlToUse, body)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	// adding the variable expanded headers to the request
-	expandedHeaders, err := ExpandMapVariable(q.Header)
-	if err != nil{
-		return "", err
+	expandedHeaders, err := expandMap(q.Header, &sentBody)
+	if err != nil {
+		return nil, err
 	}
 	for headerKey, headerValue := range expandedHeaders {
 		req.Header.Set(headerKey, headerValue)
 	}
+	// the multipart content type holds the boundary of the parts, it cannot be overridden
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
 
 	// adding the variable expanded cookies to request
-	expandedCookies, err := ExpandMapVariable(q.Cookie)
-	if err != nil{
-		return "", err
+	expandedCookies, err := expandMap(q.Cookie, &sentBody)
+	if err != nil {
+		return nil, err
 	}
 	for name, value := range expandedCookies {
 		req.AddCookie(&http.Cookie{
@@ -133,35 +362,152 @@ func (q *Query) Send(verbose bool) (string, error) {
 		})
 	}
 
-	// ending the request
+	return req, nil
+}
+
+// return the variables used by the query which are not defined in the current environment
+// and have no default value, in order of appearance. The references are only walked, the
+// request is not built: the files are not read and the built-in variables are not generated
+func (q *Query) MissingVariables() ([]string, error) {
+	missing := []string{}
+	templates := []string{q.Url, q.Body}
+	for _, values := range []map[string]string{q.Data, q.Header, q.Cookie} {
+		keys := make([]string, 0, len(values))
+		for key := range values {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+		for _, key := range keys {
+			templates = append(templates, values[key])
+		}
+	}
+	for _, template := range templates {
+		err := collectMissingVariables(template, []string{}, &missing)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return missing, nil
+}
+
+// add the variables of v which have no value and no default value to missing. The values
+// of the environment are walked as well, since they can use other variables
+func collectMissingVariables(v string, chain []string, missing *[]string) error {
+	for _, part := range SplitVariables(v) {
+		if !part.IsVariable {
+			continue
+		}
+		err := collectMissingReference(part.Text, chain, missing)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func collectMissingReference(expression string, chain []string, missing *[]string) error {
+	reference := ParseReference(expression)
+	// the name can only be known once the variables nested in it are defined
+	count := len(*missing)
+	err := collectMissingVariables(reference.Name, chain, missing)
+	if err != nil || len(*missing) > count {
+		return err
+	}
+	name, err := expandTemplate(reference.Name, chain, nil)
+	if err != nil {
+		return err
+	}
+
+	if IsExpression(name) {
+		tokens, err := tokenizeExpression(name)
+		if err != nil {
+			return err
+		}
+		for index, token := range tokens {
+			// the words which are not numbers, functions or built-in variables are variables
+			isCall := index+1 < len(tokens) && tokens[index+1].Text == "(" && !tokens[index+1].IsString
+			if token.IsString || isCall || strings.ContainsAny(token.Text, "(),") || numberRegex.MatchString(token.Text) {
+				continue
+			}
+			err := collectMissingReference(token.Text, chain, missing)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	// the sources and the built-in variables are only read when the request is sent
+	if IsDynamicVariable(name) || strings.Contains(name, ":") {
+		return nil
+	}
+	value, ok, err := environmentValue(name)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		if reference.HasDefault {
+			return collectMissingVariables(reference.Default, chain, missing)
+		}
+		if !slices.Contains(*missing, name) {
+			*missing = append(*missing, name)
+		}
+		return nil
+	}
+	// the cycles are reported when the request is sent, and the secrets cannot use variables
+	if slices.Contains(chain, name) || len(chain) >= maxExpansionDepth || IsSecretValue(value) {
+		return nil
+	}
+	return collectMissingVariables(value, append(chain[:len(chain):len(chain)], name), missing)
+}
+
+// send the request and read the whole response
+func DoRequest(req *http.Request) (*Response, error) {
+	start := time.Now()
 	res, err := client.Do(req)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	defer res.Body.Close()
 	resBody, err := io.ReadAll(res.Body)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	bodyString := string(resBody)
-	finalString := fmt.Sprintf("%s\n", bodyString)
 
-	if verbose{
-		headerJson, err := json.Marshal(res.Header)
-		if err != nil {
-			return "", err
-		}
-		headerString := string(headerJson)
-		finalString = fmt.Sprintf("url: %s\n%s\n\nbody:\n%s\n\nheaders:\n%s\n",urlToUse, res.Status, bodyString, headerString)
+	return &Response{
+		Url:        req.URL.String(),
+		Proto:      res.Proto,
+		Status:     res.Status,
+		StatusCode: res.StatusCode,
+		Header:     res.Header,
+		Body:       string(resBody),
+		StartedAt:  start,
+		Duration:   time.Since(start),
+	}, nil
+}
+
+// if verbose is true, it will display the url, status, body and headers
+// if verbose is false, it will only display the body as a string
+func (res *Response) Format(verbose bool) (string, error) {
+	if !verbose {
+		return fmt.Sprintf("%s\n", res.Body), nil
+	}
+	headerJson, err := json.Marshal(res.Header)
+	if err != nil {
+		return "", err
 	}
-	return finalString, nil
+	headerString := string(headerJson)
+	return fmt.Sprintf("url: %s\n%s\n\nbody:\n%s\n\nheaders:\n%s\n", res.Url, res.Status, res.Body, headerString), nil
 }
 
 // this function is used to append the query parameters to the
 // the url.
 // It returns the full url that should be used in the http request
 func (q Query) GetQueryUrl() (string, error) {
-	u, err := url.Parse(q.Url)
+	expandedUrl, err := ExpandVariable(q.Url)
+	if err != nil {
+		return "", err
+	}
+	u, err := url.Parse(expandedUrl)
 	if err != nil {
 		return "", err
 	}
@@ -190,6 +536,75 @@ func (q Query) GetFormParam() (io.Reader, error) {
 	return strings.NewReader(form.Encode()), nil
 }
 
+// return the path of the file to upload if the multipart value is one, ex: @./me.png.
+// A text starting with @ is escaped as @@, ex: @@john is the text @john
+func MultipartFile(value string) (string, bool) {
+	if strings.HasPrefix(value, "@") && !strings.HasPrefix(value, "@@") {
+		return value[1:], true
+	}
+	return "", false
+}
+
+// return the multipart value sending text as is, even if it starts with @
+func EscapeMultipartText(text string) string {
+	if strings.HasPrefix(text, "@") {
+		return "@" + text
+	}
+	return text
+}
+
+// return the text sent by a multipart value which is not a file
+func MultipartText(value string) string {
+	if strings.HasPrefix(value, "@@") {
+		return value[1:]
+	}
+	return value
+}
+
+// create the multipart form body that needs to be sent in the http request.
+// Values starting with @ are paths of files to upload, ex: avatar=@./me.png.
+// The @ is read before the variables are expanded, so the value of a variable is never a path.
+// It also returns the content type to use since it holds the boundary between the parts
+func (q Query) GetMultipartParam() (io.Reader, string, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for k, v := range q.Data {
+		filePath, isFile := MultipartFile(v)
+		if !isFile {
+			text, err := ExpandVariable(MultipartText(v))
+			if err != nil {
+				return nil, "", err
+			}
+			err = writer.WriteField(k, text)
+			if err != nil {
+				return nil, "", err
+			}
+			continue
+		}
+		filePath, err := ExpandVariable(filePath)
+		if err != nil {
+			return nil, "", err
+		}
+		fileContent, err := os.ReadFile(filePath)
+		if err != nil {
+			return nil, "", fmt.Errorf("while reading the file to upload for %s: %v", k, err)
+		}
+		part, err := writer.CreateFormFile(k, filepath.Base(filePath))
+		if err != nil {
+			return nil, "", err
+		}
+		_, err = part.Write(fileContent)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+	err := writer.Close()
+	if err != nil {
+		return nil, "", err
+	}
+	return &body, writer.FormDataContentType(), nil
+}
+
 // create the JSON encoded body that needs to be sent in the http request
 func (q Query) GetJsonParam() (io.Reader, error) {
 	expandedData, err := ExpandMapVariable(q.Data)
@@ -235,8 +650,7 @@ func BuildQueryTree() error {
 	}
 
 	for _, query := range queries {
-		nameParts := strings.Split(query.Name, "/")
-		QueryTree.AddQueryName(nameParts, query.Method)
+		QueryTree.AddQuery(query)
 	}
 
 	return nil
@@ -255,4 +669,26 @@ func GetQuery(name string) (*Query, error) {
 		}
 	}
 	return &existingQuery, nil
-}
\ No newline at end of file
+}
+// delete the saved query and its examples
+func DeleteQuery(query *Query) error {
+	res := db.Db.Where("query_id = ?", query.ID).Delete(&Example{})
+	if res.Error != nil {
+		return fmt.Errorf("while deleting the examples of %s: %v", query.Name, res.Error)
+	}
+	res = db.Db.Delete(query)
+	if res.Error != nil {
+		return fmt.Errorf("while deleting the query %s: %v", query.Name, res.Error)
+	}
+	return nil
+}
+
+// return all the saved queries, sorted by name
+func GetAllQueries() ([]Query, error) {
+	queries := []Query{}
+	res := db.Db.Order("name").Find(&queries)
+	if res.Error != nil {
+		return nil, fmt.Errorf("while fetching all queries: %v", res.Error)
+	}
+	return queries, nil
+}
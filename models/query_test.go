@@ -1,6 +1,9 @@
 package models
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -30,3 +33,210 @@ func TestExpandVariable(t *testing.T) {
 	}
 
 }
+
+func TestExpandDynamicVariable(t *testing.T) {
+	CurrentEnv = &Environment{Variables: map[string]string{"host": "free.fr"}}
+	ResetGeneratedValues()
+	res, err := ExpandVariable("http://%{host}%/%{$uuid}%?n=%{$randomInt(5,5)}%")
+	if err != nil {
+		t.Errorf("%v\n", err)
+		return
+	}
+	if len(GeneratedValues) != 2 {
+		t.Errorf("2 values should be generated, not %d\n", len(GeneratedValues))
+		return
+	}
+	expected := "http://free.fr/" + GeneratedValues[0].Value + "?n=5"
+	if res != expected || len(GeneratedValues[0].Value) != 36 {
+		t.Errorf("the url should be %s not %s\n", expected, res)
+	}
+
+	if _, err := ExpandVariable("%{$unknown}%"); err == nil {
+		t.Errorf("an unknown built-in variable should fail\n")
+	}
+	if _, err := ExpandVariable("%{$randomInt(9,1)}%"); err == nil {
+		t.Errorf("a min greater than the max should fail\n")
+	}
+	for _, bounds := range []string{"0,9223372036854775807", "-1,9223372036854775807", "-9223372036854775808,9223372036854775807"} {
+		if res, err := ExpandVariable("%{$randomInt(" + bounds + ")}%"); err != nil || res == "" {
+			t.Errorf("the range %s should be supported, not %s (%v)\n", bounds, res, err)
+		}
+	}
+}
+
+func TestExpandSourceVariable(t *testing.T) {
+	CurrentEnv = &Environment{Variables: map[string]string{}}
+	t.Setenv("GOURL_TEST_TOKEN", "abc")
+	tokenPath := filepath.Join(t.TempDir(), "token.txt")
+	err := os.WriteFile(tokenPath, []byte("s3cr3t\n"), 0600)
+	if err != nil {
+		t.Errorf("%v\n", err)
+		return
+	}
+	res, err := ExpandVariable("%{env:GOURL_TEST_TOKEN}%:%{file:" + tokenPath + "}%")
+	if err != nil {
+		t.Errorf("%v\n", err)
+		return
+	}
+	if res != "abc:s3cr3t" {
+		t.Errorf("the value should be abc:s3cr3t not %s\n", res)
+	}
+
+	if _, err := ExpandVariable("%{env:GOURL_TEST_MISSING}%"); err == nil {
+		t.Errorf("a missing environment variable should fail\n")
+	}
+	if _, err := ExpandVariable("%{file:./missing.txt}%"); err == nil {
+		t.Errorf("a missing file should fail\n")
+	}
+}
+
+func TestExpandDefaultVariable(t *testing.T) {
+	CurrentEnv = &Environment{Variables: map[string]string{"host": "free.fr"}}
+	for template, expected := range map[string]string{
+		"%{host:-localhost}%:%{port:-8080}%": "free.fr:8080",
+		"%{port:-}%":                         "",
+		"%{env:GOURL_TEST_MISSING:-none}%":   "none",
+		"100%%{host}% and %{host}%":          "100%{host}% and free.fr",
+		"%{}% and %{host":                    "%{}% and %{host",
+	} {
+		res, err := ExpandVariable(template)
+		if err != nil {
+			t.Errorf("%s: %v\n", template, err)
+			continue
+		}
+		if res != expected {
+			t.Errorf("%s should be expanded to %s not %s\n", template, expected, res)
+		}
+	}
+
+	_, err := ExpandVariable("%{token:?login first}%")
+	if err == nil || err.Error() != "token: login first" {
+		t.Errorf("the error should be the custom message, not %v\n", err)
+	}
+	res, err := ExpandVariable("%{host:?login first}%")
+	if err != nil || res != "free.fr" {
+		t.Errorf("an existing required variable should be expanded, not %s (%v)\n", res, err)
+	}
+}
+
+func TestVariableNames(t *testing.T) {
+	names := VariableNames("%{host}%/%{port:-80}%/%{host}%/%{token:?login first}%")
+	if strings.Join(names, ",") != "host,port,token" {
+		t.Errorf("the names should be host,port,token not %v\n", names)
+	}
+}
+
+func TestExpandNestedVariable(t *testing.T) {
+	CurrentEnv = &Environment{Variables: map[string]string{
+		"host":       "free.fr",
+		"api_url":    "https://%{host}%/api/%{version:-v2}%",
+		"env":        "prod",
+		"token_dev":  "dev-token",
+		"token_prod": "prod-token",
+		"a":          "%{b}%",
+		"b":          "%{a}%",
+		"broken":     "%{api_url}%/%{missing}%",
+	}}
+	for template, expected := range map[string]string{
+		"%{api_url}%/users":        "https://free.fr/api/v2/users",
+		"%{token_%{env}%}%":        "prod-token",
+		"%{token_%{stage:-dev}%}%": "dev-token",
+		"%{missing:-%{host}%}%":    "free.fr",
+	} {
+		res, err := ExpandVariable(template)
+		if err != nil {
+			t.Errorf("%s: %v\n", template, err)
+			continue
+		}
+		if res != expected {
+			t.Errorf("%s should be expanded to %s not %s\n", template, expected, res)
+		}
+	}
+
+	_, err := ExpandVariable("%{a}%")
+	if err == nil || !strings.Contains(err.Error(), "a -> b -> a") {
+		t.Errorf("the cycle should be reported, not %v\n", err)
+	}
+	_, err = ExpandVariable("%{broken}%")
+	if err == nil || !strings.Contains(err.Error(), "broken -> missing") {
+		t.Errorf("the resolution chain should be reported, not %v\n", err)
+	}
+}
+
+func TestMissingVariables(t *testing.T) {
+	CurrentEnv = &Environment{Variables: map[string]string{"host": "free.fr"}}
+	TemporaryVariables = map[string]string{"id": "12"}
+	defer func() { TemporaryVariables = map[string]string{} }()
+	query := Query{
+		Method: "GET",
+		Url:    "http://%{host}%:%{port:-80}%/%{version}%/%{id}%",
+		Header: map[string]string{"Authorization": "Bearer %{token:?login first}%"},
+		Data:   map[string]string{"v": "%{version}%"},
+	}
+	missing, err := query.MissingVariables()
+	if err != nil {
+		t.Errorf("%v\n", err)
+		return
+	}
+	if strings.Join(missing, ",") != "version,token" {
+		t.Errorf("the missing variables should be version,token not %v\n", missing)
+	}
+	if _, err := ExpandVariable("%{version}%"); err == nil {
+		t.Errorf("the missing variables should fail again once collected\n")
+	}
+
+	// the values, the defaults and the functions are walked, nothing is read or generated
+	CurrentEnv = &Environment{Variables: map[string]string{"api": "%{scheme}%://%{host:-localhost}%"}}
+	ResetGeneratedValues()
+	query = Query{
+		Method:      "POST",
+		Url:         "%{api}%/%{$uuid}%/%{file:/does/not/exist}%",
+		Body:        "%{base64(concat(user, \":\", 'x'))}%",
+		Data:        map[string]string{"avatar": "@/does/not/exist"},
+		IsMultipart: true,
+	}
+	missing, err = query.MissingVariables()
+	if err != nil {
+		t.Errorf("%v\n", err)
+		return
+	}
+	if strings.Join(missing, ",") != "scheme,user" {
+		t.Errorf("the missing variables should be scheme,user not %v\n", missing)
+	}
+	if len(GeneratedValues) != 0 {
+		t.Errorf("the built-in variables should not be generated: %v\n", GeneratedValues)
+	}
+}
+
+func TestGetMultipartParam(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "me.png")
+	err := os.WriteFile(filePath, []byte("png"), 0600)
+	if err != nil {
+		t.Errorf("%v\n", err)
+		return
+	}
+	TemporaryVariables = map[string]string{"who": "@/etc/hostname"}
+	defer func() { TemporaryVariables = map[string]string{} }()
+	query := Query{
+		Method:      "POST",
+		Url:         "https://example.com/upload",
+		Data:        map[string]string{"name": "%{who}%", "handle": "@@john", "avatar": "@" + filePath},
+		IsMultipart: true,
+	}
+	req, err := query.NewRequest()
+	if err != nil {
+		t.Errorf("%v\n", err)
+		return
+	}
+	err = req.ParseMultipartForm(1 << 20)
+	if err != nil {
+		t.Errorf("%v\n", err)
+		return
+	}
+	if req.FormValue("name") != "@/etc/hostname" || req.FormValue("handle") != "@john" {
+		t.Errorf("the texts should be sent as is, not %v\n", req.MultipartForm.Value)
+	}
+	if files := req.MultipartForm.File; len(files) != 1 || files["avatar"][0].Filename != "me.png" {
+		t.Errorf("only the avatar should be uploaded, not %v\n", files)
+	}
+}
@@ -2,58 +2,241 @@ package models
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 )
 
 type QueryTreeNode struct {
 	CurrentNode string                    // name of the current node
 	SubNodes    map[string]*QueryTreeNode // list of the next categories
-	Leaves      []string                  // list of the queries at this level in the tree
+	Leaves      []QueryTreeLeaf           // list of the queries at this level in the tree
 }
 
-var QueryTree = QueryTreeNode{
-	SubNodes: map[string]*QueryTreeNode{},
-	Leaves:   []string{},
+// a saved query as seen from the tree
+type QueryTreeLeaf struct {
+	Name   string   `json:"name"` // last part of the query name, ex: post_message
+	Method string   `json:"method"`
+	Path   string   `json:"path"` // full slash separated name of the query, ex: demo/test/post_message
+	Tags   []string `json:"tags"`
+}
+
+// return true if the query has the tag, ignoring the case
+func (leaf QueryTreeLeaf) HasTag(tag string) bool {
+	for _, existingTag := range leaf.Tags {
+		if strings.EqualFold(existingTag, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// serializable version of the tree used by `gourl list --format json`
+type QueryTreeJson struct {
+	Name    string          `json:"name"`
+	Count   int             `json:"count"` // number of queries in this folder, sub folders included
+	Queries []QueryTreeLeaf `json:"queries"`
+	Folders []QueryTreeJson `json:"folders"`
+}
+
+var QueryTree = NewQueryTreeNode("")
+
+func NewQueryTreeNode(name string) *QueryTreeNode {
+	return &QueryTreeNode{
+		CurrentNode: name,
+		SubNodes:    map[string]*QueryTreeNode{},
+		Leaves:      []QueryTreeLeaf{},
+	}
 }
 
 func (node *QueryTreeNode) AddQueryName(queryNameParts []string, method string) {
+	node.addLeaf(queryNameParts, QueryTreeLeaf{
+		Name:   queryNameParts[len(queryNameParts)-1],
+		Method: method,
+		Path:   strings.Join(queryNameParts, "/"),
+		Tags:   []string{},
+	})
+}
+
+func (node *QueryTreeNode) AddQuery(query Query) {
+	queryNameParts := strings.Split(query.Name, "/")
+	node.addLeaf(queryNameParts, QueryTreeLeaf{
+		Name:   queryNameParts[len(queryNameParts)-1],
+		Method: query.Method,
+		Path:   query.Name,
+		Tags:   query.TagList(),
+	})
+}
+
+func (node *QueryTreeNode) addLeaf(queryNameParts []string, leaf QueryTreeLeaf) {
 	if len(queryNameParts) == 1 {
-		leaveName := fmt.Sprintf("%s (%s)", queryNameParts[0], method)
-		node.Leaves = append(node.Leaves, leaveName)
+		node.Leaves = append(node.Leaves, leaf)
 		return
 	}
 
 	existingNode, ok := node.SubNodes[queryNameParts[0]]
-	if ok {
-		existingNode.AddQueryName(queryNameParts[1:], method)
-	} else {
-		newNode := QueryTreeNode{
-			CurrentNode: queryNameParts[0],
-			SubNodes:    map[string]*QueryTreeNode{},
-			Leaves:      []string{},
+	if !ok {
+		existingNode = NewQueryTreeNode(queryNameParts[0])
+		node.SubNodes[queryNameParts[0]] = existingNode
+	}
+	existingNode.addLeaf(queryNameParts[1:], leaf)
+}
+
+// return the node matching the slash separated prefix, ex: demo/api
+// it returns nil if no such folder exists
+func (node *QueryTreeNode) Find(prefix string) *QueryTreeNode {
+	current := node
+	for _, part := range strings.Split(strings.Trim(prefix, "/"), "/") {
+		if part == "" {
+			continue
+		}
+		subNode, ok := current.SubNodes[part]
+		if !ok {
+			return nil
 		}
-		node.SubNodes[queryNameParts[0]] = &newNode
-		newNode.AddQueryName(queryNameParts[1:], method)
+		current = subNode
 	}
+	return current
+}
 
+// return a copy of the tree keeping only the leaves for which keep returns true.
+// Folders left without any query are removed.
+func (node *QueryTreeNode) Filter(keep func(leaf QueryTreeLeaf) bool) *QueryTreeNode {
+	res := NewQueryTreeNode(node.CurrentNode)
+	for _, leaf := range node.Leaves {
+		if keep(leaf) {
+			res.Leaves = append(res.Leaves, leaf)
+		}
+	}
+	for name, subNode := range node.SubNodes {
+		filtered := subNode.Filter(keep)
+		if len(filtered.Leaves) > 0 || len(filtered.SubNodes) > 0 {
+			res.SubNodes[name] = filtered
+		}
+	}
+	return res
+}
+
+// return the sub nodes sorted by name so the output is always the same
+func (node *QueryTreeNode) SortedSubNodes() []*QueryTreeNode {
+	res := make([]*QueryTreeNode, 0, len(node.SubNodes))
+	for _, subNode := range node.SubNodes {
+		res = append(res, subNode)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].CurrentNode < res[j].CurrentNode
+	})
+	return res
+}
+
+// return the leaves sorted by name so the output is always the same
+func (node *QueryTreeNode) SortedLeaves() []QueryTreeLeaf {
+	res := append([]QueryTreeLeaf{}, node.Leaves...)
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].Name < res[j].Name
+	})
+	return res
 }
 
 // print all the node in the tree until the requested depth is reached
 // pass depth = -1 for printing all the tree
 func (node *QueryTreeNode) Print(depth int, prefix string) string {
-	res := fmt.Sprintf("%s/%s\n", prefix, node.CurrentNode)
-	// fmt.Println(res)
-	for _, leaf := range node.Leaves {
-		res += fmt.Sprintf("%s/%s\n", prefix+"    ", leaf)
+	name := node.CurrentNode
+	if name == "" {
+		name = "/"
+	}
+	return name + "\n" + node.printChildren(depth, prefix)
+}
+
+func (node *QueryTreeNode) printChildren(depth int, prefix string) string {
+	res := ""
+	leaves := node.SortedLeaves()
+	subNodes := node.SortedSubNodes()
+	nbChildren := len(leaves) + len(subNodes)
+	childIndex := 0
+
+	// folders are displayed first, then the queries.
+	// Once the depth is reached, folders are displayed collapsed with their number of queries
+	for _, subNode := range subNodes {
+		childIndex += 1
+		branch, indent := treeBranch(childIndex == nbChildren)
+		if depth == 0 {
+			count := subNode.Count()
+			unit := "queries"
+			if count == 1 {
+				unit = "query"
+			}
+			res += fmt.Sprintf("%s%s%s/ (%d %s)\n", prefix, branch, subNode.CurrentNode, count, unit)
+			continue
+		}
+		res += fmt.Sprintf("%s%s%s/\n", prefix, branch, subNode.CurrentNode)
+		subDepth := depth
+		if subDepth > 0 {
+			subDepth -= 1
+		}
+		res += subNode.printChildren(subDepth, prefix+indent)
+	}
+	for _, leaf := range leaves {
+		childIndex += 1
+		branch, _ := treeBranch(childIndex == nbChildren)
+		res += fmt.Sprintf("%s%s%s (%s)\n", prefix, branch, leaf.Name, leaf.Method)
+	}
+	return res
+}
+
+// return the number of queries saved under this node, sub folders included
+func (node *QueryTreeNode) Count() int {
+	res := len(node.Leaves)
+	for _, subNode := range node.SubNodes {
+		res += subNode.Count()
+	}
+	return res
+}
+
+// return all the queries saved under this node, sub folders included, sorted by path
+func (node *QueryTreeNode) AllLeaves() []QueryTreeLeaf {
+	res := append([]QueryTreeLeaf{}, node.Leaves...)
+	for _, subNode := range node.SubNodes {
+		res = append(res, subNode.AllLeaves()...)
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].Path < res[j].Path
+	})
+	return res
+}
+
+// return the drawing of the branch and the indentation to use for its children
+func treeBranch(isLast bool) (string, string) {
+	if isLast {
+		return "└── ", "    "
+	}
+	return "├── ", "│   "
+}
+
+// build the serializable version of the tree until the requested depth is reached.
+// Just like Print, folders beyond the depth are kept but without their content.
+// pass depth = -1 for the whole tree
+func (node *QueryTreeNode) ToJson(depth int) QueryTreeJson {
+	res := QueryTreeJson{
+		Name:    node.CurrentNode,
+		Count:   node.Count(),
+		Queries: node.SortedLeaves(),
+		Folders: []QueryTreeJson{},
 	}
-	if depth == 0 || len(node.SubNodes) == 0 {
-		return res
-	} else {
-		if depth > 0 {
-			depth -= 1
+	for _, subNode := range node.SortedSubNodes() {
+		if depth == 0 {
+			res.Folders = append(res.Folders, QueryTreeJson{
+				Name:    subNode.CurrentNode,
+				Count:   subNode.Count(),
+				Queries: []QueryTreeLeaf{},
+				Folders: []QueryTreeJson{},
+			})
+			continue
 		}
-		for _, subNode := range node.SubNodes {
-			res += subNode.Print(depth, prefix+"    ")
+		subDepth := depth
+		if subDepth > 0 {
+			subDepth -= 1
 		}
+		res.Folders = append(res.Folders, subNode.ToJson(subDepth))
 	}
 	return res
 }
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func buildTestTree() *QueryTreeNode {
+	tree := NewQueryTreeNode("")
+	for name, method := range map[string]string{
+		"test/google.ca":                    "GET",
+		"demo/test/google.ca":               "GET",
+		"demo/jsonplaceholder/post-comment": "POST",
+		"demo/jsonplaceholder/get-comments": "GET",
+		"ping":                              "HEAD",
+	} {
+		tree.AddQueryName(strings.Split(name, "/"), method)
+	}
+	return tree
+}
+
+func TestQueryTreePrint(t *testing.T) {
+	expected := `/
+├── demo/
+│   ├── jsonplaceholder/
+│   │   ├── get-comments (GET)
+│   │   └── post-comment (POST)
+│   └── test/
+│       └── google.ca (GET)
+├── test/
+│   └── google.ca (GET)
+└── ping (HEAD)
+`
+	// the map used to build the tree is not ordered, the output must still be the same
+	for i := 0; i < 10; i++ {
+		res := buildTestTree().Print(-1, "")
+		if res != expected {
+			t.Errorf("unexpected tree:\n%s\nexpected:\n%s", res, expected)
+			return
+		}
+	}
+}
+
+func TestQueryTreeDepthAndFilter(t *testing.T) {
+	tree := buildTestTree()
+
+	expected := `/
+├── demo/ (3 queries)
+├── test/ (1 query)
+└── ping (HEAD)
+`
+	res := tree.Print(0, "")
+	if res != expected {
+		t.Errorf("unexpected tree with depth 0:\n%s\nexpected:\n%s", res, expected)
+	}
+
+	subTree := tree.Find("demo/jsonplaceholder/")
+	if subTree == nil {
+		t.Errorf("demo/jsonplaceholder should exist")
+		return
+	}
+	posts := subTree.Filter(func(leaf QueryTreeLeaf) bool { return leaf.Method == "POST" })
+	if len(posts.Leaves) != 1 || posts.Leaves[0].Path != "demo/jsonplaceholder/post-comment" {
+		t.Errorf("only demo/jsonplaceholder/post-comment should be kept, not %v", posts.Leaves)
+	}
+
+	if tree.Find("demo/unknown") != nil {
+		t.Errorf("demo/unknown should not exist")
+	}
+}
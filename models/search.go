@@ -0,0 +1,109 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/nakurai/gourl/utils"
+)
+
+// a saved query matching a search, with the fields that matched
+type SearchResult struct {
+	Query         Query
+	Score         int
+	MatchedFields []string // ex: name, url, header
+}
+
+// a field of the query that can be searched, and how much a match in this field is worth
+type searchField struct {
+	Name   string
+	Values []string
+	Weight int
+}
+
+func (q Query) searchFields() []searchField {
+	mapValues := func(m JSONMap) []string {
+		res := []string{}
+		for key, value := range m {
+			res = append(res, key+"="+value)
+		}
+		return res
+	}
+	return []searchField{
+		{Name: "name", Values: []string{q.Name}, Weight: 4},
+		{Name: "url", Values: []string{q.Url}, Weight: 3},
+		{Name: "header", Values: mapValues(q.Header), Weight: 2},
+		{Name: "data", Values: mapValues(q.Data), Weight: 1},
+		{Name: "body", Values: []string{q.Body}, Weight: 1},
+	}
+}
+
+// search the text in the queries' name, url, headers and data.
+// mode can be:
+//   - substring: the text must appear as is, ignoring the case
+//   - regex: the text is a regular expression
+//   - fuzzy: the characters of the text must appear in the same order, see utils.FuzzyScore
+//
+// the results are sorted from the best match to the worst one
+func SearchQueries(queries []Query, text string, mode string) ([]SearchResult, error) {
+	var match func(value string) (int, bool)
+	switch mode {
+	case "substring":
+		lowerText := strings.ToLower(text)
+		match = func(value string) (int, bool) {
+			lowerValue := strings.ToLower(value)
+			if !strings.Contains(lowerValue, lowerText) {
+				return 0, false
+			}
+			if lowerValue == lowerText {
+				return 3, true
+			}
+			if strings.HasPrefix(lowerValue, lowerText) {
+				return 2, true
+			}
+			return 1, true
+		}
+	case "regex":
+		textRegex, err := regexp.Compile(text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression %s: %v", text, err)
+		}
+		match = func(value string) (int, bool) {
+			return 1, textRegex.MatchString(value)
+		}
+	case "fuzzy":
+		match = func(value string) (int, bool) {
+			return utils.FuzzyScore(text, value)
+		}
+	default:
+		return nil, fmt.Errorf("unknown search mode %s. It must be substring, regex or fuzzy", mode)
+	}
+
+	results := []SearchResult{}
+	for _, query := range queries {
+		result := SearchResult{Query: query}
+		for _, field := range query.searchFields() {
+			bestScore := 0
+			for _, value := range field.Values {
+				score, ok := match(value)
+				if ok && score > bestScore {
+					bestScore = score
+				}
+			}
+			if bestScore > 0 {
+				result.Score += bestScore * field.Weight
+				result.MatchedFields = append(result.MatchedFields, field.Name)
+			}
+		}
+		if result.Score > 0 {
+			results = append(results, result)
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	return results, nil
+}
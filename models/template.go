@@ -0,0 +1,129 @@
+package models
+
+import (
+	"strings"
+)
+
+// a piece of a string, either a literal text or a variable
+type TemplatePart struct {
+	Text       string // the literal text, or the reference to the variable, ex: port:-8080
+	IsVariable bool
+}
+
+// split v between its literal texts and its variables, ex: http://%{host}%/api
+// gives the literal http://, the variable host and the literal /api.
+// %%{ is an escaped %{, it is kept as a literal %{
+func SplitVariables(v string) []TemplatePart {
+	parts := []TemplatePart{}
+	literal := ""
+	for index := 0; index < len(v); {
+		if strings.HasPrefix(v[index:], "%%{") {
+			literal += "%{"
+			index += 3
+			continue
+		}
+		if strings.HasPrefix(v[index:], "%{") {
+			end := referenceEnd(v, index)
+			// an unterminated or empty reference is not a variable
+			if end != -1 && end > index+2 {
+				if literal != "" {
+					parts = append(parts, TemplatePart{Text: literal})
+					literal = ""
+				}
+				parts = append(parts, TemplatePart{Text: v[index+2 : end], IsVariable: true})
+				index = end + 2
+				continue
+			}
+		}
+		literal += v[index : index+1]
+		index++
+	}
+	if literal != "" {
+		parts = append(parts, TemplatePart{Text: literal})
+	}
+	return parts
+}
+
+// return the index of the }% closing the reference starting at start, or -1
+func referenceEnd(v string, start int) int {
+	depth := 0
+	for index := start; index < len(v); {
+		switch {
+		case strings.HasPrefix(v[index:], "%%{"):
+			index += 3
+		case strings.HasPrefix(v[index:], "%{"):
+			depth++
+			index += 2
+		case strings.HasPrefix(v[index:], "}%"):
+			depth--
+			if depth == 0 {
+				return index
+			}
+			index += 2
+		default:
+			index++
+		}
+	}
+	return -1
+}
+
+// return the names of the variables used in v, in order of appearance and without duplicates
+func VariableNames(v string) []string {
+	names := []string{}
+	seen := map[string]bool{}
+	for _, part := range SplitVariables(v) {
+		if !part.IsVariable {
+			continue
+		}
+		name := ParseReference(part.Text).Name
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// a reference to a variable, ex: %{port:-8080}% or %{token:?login first}%
+type VariableReference struct {
+	Name       string
+	HasDefault bool
+	Default    string // used when the variable is missing, after :-
+	Required   bool
+	Message    string // the error displayed when the variable is missing, after :?
+}
+
+// parse the reference. The :- and :? of the references nested in the name, ex:
+// %{token_%{env:-dev}%}%, belong to them, and the ones in quoted strings are literals
+func ParseReference(expression string) VariableReference {
+	for index := 0; index < len(expression); index++ {
+		if strings.HasPrefix(expression[index:], "%{") {
+			if end := referenceEnd(expression, index); end != -1 {
+				index = end + 1
+			}
+			continue
+		}
+		// the strings of the functions can contain :- or :?, ex: concat(a, ":-", b)
+		if quote := expression[index]; quote == '"' || quote == '\'' {
+			if end := strings.IndexByte(expression[index+1:], quote); end != -1 {
+				index += end + 1
+			}
+			continue
+		}
+		switch {
+		case strings.HasPrefix(expression[index:], ":-"):
+			return VariableReference{
+				Name:       strings.TrimSpace(expression[:index]),
+				HasDefault: true,
+				Default:    expression[index+2:],
+			}
+		case strings.HasPrefix(expression[index:], ":?"):
+			return VariableReference{
+				Name:     strings.TrimSpace(expression[:index]),
+				Required: true,
+				Message:  strings.TrimSpace(expression[index+2:]),
+			}
+		}
+	}
+	return VariableReference{Name: strings.TrimSpace(expression)}
+}
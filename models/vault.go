@@ -0,0 +1,269 @@
+package models
+
+import (
+	"cmp"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/nakurai/gourl/db"
+	"github.com/nakurai/gourl/utils"
+)
+
+// the prefix of the encrypted values of the secret variables, followed by the
+// base64 salt of the key and the base64 encrypted value, separated by :
+const secretPrefix = "secret:v1:"
+
+// return the passphrase of the vault, set by the cli to ask it when needed
+var PassphraseProvider func() (string, error)
+
+// the keys of the vault already derived, by base64 salt
+var vaultKeys = map[string][]byte{}
+
+// the secrets decrypted by this process, masked by MaskSecrets
+var revealedSecrets = []string{}
+
+// return true if the value of the variable is an encrypted secret
+func IsSecretValue(value string) bool {
+	return strings.HasPrefix(value, secretPrefix)
+}
+
+// return the base64 salt and the encrypted value of a secret
+func splitSecret(value string) (string, string, error) {
+	salt, sealed, ok := strings.Cut(strings.TrimPrefix(value, secretPrefix), ":")
+	if !IsSecretValue(value) || !ok {
+		return "", "", fmt.Errorf("the secret is malformed")
+	}
+	return salt, sealed, nil
+}
+
+// return the key of the vault for this salt. It comes from the GOURL_VAULT_KEY
+// environment variable written by gourl vault unlock, or from the passphrase
+func vaultKey(salt string) ([]byte, error) {
+	if key, ok := vaultKeys[salt]; ok {
+		return key, nil
+	}
+	if unlocked, ok := strings.CutPrefix(os.Getenv("GOURL_VAULT_KEY"), salt+":"); ok {
+		key, err := base64.StdEncoding.DecodeString(unlocked)
+		if err == nil {
+			vaultKeys[salt] = key
+			return key, nil
+		}
+	}
+	if PassphraseProvider == nil {
+		return nil, fmt.Errorf("the secrets are locked, the passphrase is needed")
+	}
+	passphrase, err := PassphraseProvider()
+	if err != nil {
+		return nil, err
+	}
+	return deriveVaultKey(passphrase, salt)
+}
+
+func deriveVaultKey(passphrase string, salt string) ([]byte, error) {
+	saltBytes, err := base64.StdEncoding.DecodeString(salt)
+	if err != nil {
+		return nil, fmt.Errorf("the salt of the secret is malformed")
+	}
+	key, err := utils.DeriveKey(passphrase, saltBytes, utils.KeyDerivationIterations)
+	if err != nil {
+		return nil, err
+	}
+	vaultKeys[salt] = key
+	return key, nil
+}
+
+// decrypt the value of a secret variable. The result is masked by MaskSecrets
+func DecryptSecret(value string) (string, error) {
+	salt, sealed, err := splitSecret(value)
+	if err != nil {
+		return "", err
+	}
+	key, err := vaultKey(salt)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := utils.Decrypt(key, sealed)
+	if err != nil {
+		// the next attempt will ask the passphrase again
+		delete(vaultKeys, salt)
+		return "", err
+	}
+	if plaintext != "" && !slices.Contains(revealedSecrets, plaintext) {
+		revealedSecrets = append(revealedSecrets, plaintext)
+	}
+	return plaintext, nil
+}
+
+// encrypt the value of a new secret variable, with the key of the existing secrets
+func EncryptSecret(plaintext string) (string, error) {
+	secrets, err := getAllSecrets()
+	if err != nil {
+		return "", err
+	}
+	salt := ""
+	if len(secrets) > 0 {
+		// check the passphrase is the one of the existing secrets
+		_, err := DecryptSecret(secrets[0].value)
+		if err != nil {
+			return "", err
+		}
+		salt, _, _ = splitSecret(secrets[0].value)
+	} else {
+		newSalt, err := utils.NewSalt()
+		if err != nil {
+			return "", err
+		}
+		salt = base64.StdEncoding.EncodeToString(newSalt)
+	}
+	key, err := vaultKey(salt)
+	if err != nil {
+		return "", err
+	}
+	return sealSecret(key, salt, plaintext)
+}
+
+func sealSecret(key []byte, salt string, plaintext string) (string, error) {
+	sealed, err := utils.Encrypt(key, plaintext)
+	if err != nil {
+		return "", err
+	}
+	return secretPrefix + salt + ":" + sealed, nil
+}
+
+// a secret variable of an environment
+type storedSecret struct {
+	env   *Environment
+	name  string
+	value string
+}
+
+// return the secret variables of all the environments
+func getAllSecrets() ([]storedSecret, error) {
+	envs, err := GetAllEnvs()
+	if err != nil {
+		return nil, err
+	}
+	secrets := []storedSecret{}
+	for index := range envs {
+		for name, value := range envs[index].Variables {
+			if IsSecretValue(value) {
+				secrets = append(secrets, storedSecret{env: &envs[index], name: name, value: value})
+			}
+		}
+	}
+	slices.SortFunc(secrets, func(a, b storedSecret) int {
+		return cmp.Or(cmp.Compare(a.env.Name, b.env.Name), cmp.Compare(a.name, b.name))
+	})
+	return secrets, nil
+}
+
+// return the number of secret variables in all the environments
+func CountSecrets() (int, error) {
+	secrets, err := getAllSecrets()
+	return len(secrets), err
+}
+
+// check the passphrase and return the value of GOURL_VAULT_KEY unlocking the
+// secrets without asking the passphrase again
+func UnlockVault() (string, error) {
+	secrets, err := getAllSecrets()
+	if err != nil {
+		return "", err
+	}
+	if len(secrets) == 0 {
+		return "", fmt.Errorf("there is no secret variable. Use `gourl var add --data key=value --secret true` to add one")
+	}
+	_, err = DecryptSecret(secrets[0].value)
+	if err != nil {
+		return "", err
+	}
+	salt, _, _ := splitSecret(secrets[0].value)
+	return salt + ":" + base64.StdEncoding.EncodeToString(vaultKeys[salt]), nil
+}
+
+// return an error if the passphrase is not the one of the secrets. The key is derived
+// from the passphrase itself, the keys unlocked by GOURL_VAULT_KEY are not used
+func CheckPassphrase(passphrase string) error {
+	secrets, err := getAllSecrets()
+	if err != nil || len(secrets) == 0 {
+		return err
+	}
+	salt, sealed, err := splitSecret(secrets[0].value)
+	if err != nil {
+		return err
+	}
+	saltBytes, err := base64.StdEncoding.DecodeString(salt)
+	if err != nil {
+		return fmt.Errorf("the salt of the secret is malformed")
+	}
+	key, err := utils.DeriveKey(passphrase, saltBytes, utils.KeyDerivationIterations)
+	if err != nil {
+		return err
+	}
+	_, err = utils.Decrypt(key, sealed)
+	return err
+}
+
+// encrypt all the secrets again with a new key derived from the passphrase and a
+// new salt. It is used to rotate the key, or to change the passphrase
+func RekeyVault(newPassphrase string) (int, error) {
+	secrets, err := getAllSecrets()
+	if err != nil {
+		return 0, err
+	}
+	newSalt, err := utils.NewSalt()
+	if err != nil {
+		return 0, err
+	}
+	salt := base64.StdEncoding.EncodeToString(newSalt)
+	newKey, err := deriveVaultKey(newPassphrase, salt)
+	if err != nil {
+		return 0, err
+	}
+
+	// everything is decrypted first, so nothing is saved if a secret cannot be decrypted
+	for index, secret := range secrets {
+		plaintext, err := DecryptSecret(secret.value)
+		if err != nil {
+			return 0, fmt.Errorf("while decrypting %s of the %s environment: %v", secret.name, secret.env.Name, err)
+		}
+		secrets[index].value, err = sealSecret(newKey, salt, plaintext)
+		if err != nil {
+			return 0, err
+		}
+	}
+	changedEnvs := map[string]*Environment{}
+	for _, secret := range secrets {
+		secret.env.Variables[secret.name] = secret.value
+		changedEnvs[secret.env.Name] = secret.env
+	}
+	tx := db.Db.Begin()
+	for _, env := range changedEnvs {
+		res := tx.Save(env)
+		if res.Error != nil {
+			tx.Rollback()
+			return 0, fmt.Errorf("while saving the secrets of the %s environment: %v", env.Name, res.Error)
+		}
+	}
+	res := tx.Commit()
+	if res.Error != nil {
+		return 0, fmt.Errorf("while saving the secrets: %v", res.Error)
+	}
+	return len(secrets), nil
+}
+
+// replace the secrets decrypted by this process by ********
+func MaskSecrets(s string) string {
+	secrets := slices.Clone(revealedSecrets)
+	// the longest first, in case a secret contains another one
+	slices.SortFunc(secrets, func(a, b string) int {
+		return len(b) - len(a)
+	})
+	for _, secret := range secrets {
+		s = strings.ReplaceAll(s, secret, "********")
+	}
+	return s
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nakurai/gourl/db"
+	"github.com/nakurai/gourl/utils"
+)
+
+func TestSecretVariables(t *testing.T) {
+	utils.DataDirPath = t.TempDir()
+	err := InitWorkspace()
+	if err != nil {
+		t.Errorf("%v\n", err)
+		return
+	}
+	passphrase := "correct horse"
+	PassphraseProvider = func() (string, error) { return passphrase, nil }
+	defer func() {
+		PassphraseProvider = nil
+		vaultKeys = map[string][]byte{}
+		revealedSecrets = []string{}
+	}()
+
+	encrypted, err := EncryptSecret("s3cr3t")
+	if err != nil {
+		t.Errorf("%v\n", err)
+		return
+	}
+	if !IsSecretValue(encrypted) || strings.Contains(encrypted, "s3cr3t") {
+		t.Errorf("the secret should be encrypted, not %s\n", encrypted)
+		return
+	}
+	CurrentEnv.Variables["api_token"] = encrypted
+	db.Db.Save(CurrentEnv)
+
+	res, err := ExpandVariable("Bearer %{api_token}%")
+	if err != nil || res != "Bearer s3cr3t" {
+		t.Errorf("the secret should be decrypted, not %s (%v)\n", res, err)
+	}
+	if masked := MaskSecrets("token=s3cr3t"); masked != "token=********" {
+		t.Errorf("the secret should be masked, not %s\n", masked)
+	}
+
+	passphrase = "battery staple"
+	count, err := RekeyVault(passphrase)
+	if err != nil || count != 1 {
+		t.Errorf("1 secret should be encrypted again, not %d (%v)\n", count, err)
+		return
+	}
+	vaultKeys = map[string][]byte{}
+	env, _ := GetEnv(CurrentEnv.Name)
+	CurrentEnv = env
+	res, err = ExpandVariable("%{api_token}%")
+	if err != nil || res != "s3cr3t" {
+		t.Errorf("the secret should be decrypted with the new passphrase, not %s (%v)\n", res, err)
+	}
+
+	// the key is cached, the passphrase itself must be checked
+	if CheckPassphrase("correct horse") == nil || CheckPassphrase("battery staple") != nil {
+		t.Errorf("only the new passphrase should be accepted\n")
+	}
+
+	vaultKeys = map[string][]byte{}
+	passphrase = "correct horse"
+	if _, err := ExpandVariable("%{api_token}%"); err == nil {
+		t.Errorf("the old passphrase should not decrypt the secret anymore\n")
+	}
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"github.com/nakurai/gourl/db"
+)
+
+// open the database of the workspace, create its tables if needed, and load the
+// saved queries and the current environment
+func InitWorkspace() error {
+	err := db.Init()
+	if err != nil {
+		return err
+	}
+
+	err = db.Db.AutoMigrate(&Query{}, &Environment{}, &Example{})
+	if err != nil {
+		return err
+	}
+
+	err = BuildQueryTree()
+	if err != nil {
+		return err
+	}
+
+	return InitEnvironment()
+}
@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"regexp"
+)
+
+// the number of pbkdf2 iterations used to derive a key from a passphrase
+const KeyDerivationIterations = 600000
+
+// the variables whose name matches this are considered secret, ex: api_token, db_password
+var secretNameRegex = regexp.MustCompile(`(?i)(secret|token|passw(or)?d|pwd|api_?key|credential|private|authorization)`)
+
+// return true if the variable is likely to hold a secret, judging by its name
+func IsSecretName(name string) bool {
+	return secretNameRegex.MatchString(name)
+}
+
+// return a random salt to derive a key from a passphrase
+func NewSalt() ([]byte, error) {
+	salt := make([]byte, 16)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return nil, fmt.Errorf("while generating a salt: %v", err)
+	}
+	return salt, nil
+}
+
+// derive an AES-256 key from the passphrase
+func DeriveKey(passphrase string, salt []byte, iterations int) ([]byte, error) {
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, iterations, 32)
+	if err != nil {
+		return nil, fmt.Errorf("while deriving the key: %v", err)
+	}
+	return key, nil
+}
+
+// encrypt the text with AES-GCM. The result is the base64 encoded nonce and ciphertext
+func Encrypt(key []byte, plaintext string) (string, error) {
+	gcm, err := newGcm(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return "", fmt.Errorf("while generating a nonce: %v", err)
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// decrypt a text encrypted by Encrypt. It fails if the key is not the right one
+func Decrypt(key []byte, encrypted string) (string, error) {
+	gcm, err := newGcm(key)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil || len(sealed) < gcm.NonceSize() {
+		return "", fmt.Errorf("the encrypted value is malformed")
+	}
+	plaintext, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("the value cannot be decrypted, the passphrase is probably wrong")
+	}
+	return string(plaintext), nil
+}
+
+func newGcm(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key: %v", err)
+	}
+	return cipher.NewGCM(block)
+}
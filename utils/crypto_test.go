@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"testing"
+)
+
+func TestEncrypt(t *testing.T) {
+	salt, err := NewSalt()
+	if err != nil {
+		t.Errorf("%v\n", err)
+		return
+	}
+	key, err := DeriveKey("correct horse", salt, 1000)
+	if err != nil {
+		t.Errorf("%v\n", err)
+		return
+	}
+	encrypted, err := Encrypt(key, "s3cr3t")
+	if err != nil {
+		t.Errorf("%v\n", err)
+		return
+	}
+	decrypted, err := Decrypt(key, encrypted)
+	if err != nil || decrypted != "s3cr3t" {
+		t.Errorf("the value should be decrypted, not %s (%v)\n", decrypted, err)
+	}
+
+	wrongKey, _ := DeriveKey("wrong horse", salt, 1000)
+	if _, err := Decrypt(wrongKey, encrypted); err == nil {
+		t.Errorf("a wrong passphrase should not decrypt the value\n")
+	}
+}
+
+func TestIsSecretName(t *testing.T) {
+	for name, expected := range map[string]bool{
+		"api_token":     true,
+		"DB_PASSWORD":   true,
+		"apikey":        true,
+		"client_secret": true,
+		"host":          false,
+		"user_id":       false,
+	} {
+		if IsSecretName(name) != expected {
+			t.Errorf("IsSecretName(%s) should be %v\n", name, expected)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package utils
+
+import "slices"
+
+// return the differences between two lists of lines, based on their longest common subsequence.
+// Removed lines start with "- ", added lines with "+ ". Identical lines are not returned,
+// so an empty list means both lists are the same
+func DiffLines(before []string, after []string) []string {
+	// the lines shared at the start and at the end are not part of the differences
+	for len(before) > 0 && len(after) > 0 && before[0] == after[0] {
+		before, after = before[1:], after[1:]
+	}
+	for len(before) > 0 && len(after) > 0 && before[len(before)-1] == after[len(after)-1] {
+		before, after = before[:len(before)-1], after[:len(after)-1]
+	}
+	diff := []string{}
+	diffRange(before, after, &diff)
+	return diff
+}
+
+// append the differences between before and after to diff. The longest common subsequence
+// is found with Hirschberg's algorithm: before is split in two halves, and after where the
+// subsequences of both halves are the longest, so only two rows of lengths are kept in memory.
+// The memory is proportional to len(before)+len(after), but the time is still proportional
+// to len(before)*len(after)
+func diffRange(before []string, after []string, diff *[]string) {
+	switch {
+	case len(before) == 0:
+		for _, line := range after {
+			*diff = append(*diff, "+ "+line)
+		}
+		return
+	case len(after) == 0:
+		for _, line := range before {
+			*diff = append(*diff, "- "+line)
+		}
+		return
+	case len(before) == 1:
+		index := slices.Index(after, before[0])
+		if index == -1 {
+			*diff = append(*diff, "- "+before[0])
+			diffRange(nil, after, diff)
+			return
+		}
+		diffRange(nil, after[:index], diff)
+		diffRange(nil, after[index+1:], diff)
+		return
+	}
+
+	middle := len(before) / 2
+	forward := lcsLengths(before[:middle], after)
+	backward := lcsLengths(reversed(before[middle:]), reversed(after))
+	split, longest := 0, -1
+	for j := 0; j <= len(after); j++ {
+		// backward is indexed by the number of lines at the end of after
+		if length := forward[j] + backward[len(after)-j]; length > longest {
+			split, longest = j, length
+		}
+	}
+	diffRange(before[:middle], after[:split], diff)
+	diffRange(before[middle:], after[split:], diff)
+}
+
+// return the lengths of the longest common subsequences of before and after[:j], for each j
+func lcsLengths(before []string, after []string) []int {
+	previous := make([]int, len(after)+1)
+	current := make([]int, len(after)+1)
+	for _, line := range before {
+		for j := range after {
+			if line == after[j] {
+				current[j+1] = previous[j] + 1
+			} else {
+				current[j+1] = max(current[j], previous[j+1])
+			}
+		}
+		previous, current = current, previous
+	}
+	return previous
+}
+
+func reversed(lines []string) []string {
+	res := slices.Clone(lines)
+	slices.Reverse(res)
+	return res
+}
@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestDiffLines(t *testing.T) {
+	diff := DiffLines([]string{"a", "b", "c"}, []string{"a", "b", "c"})
+	if len(diff) != 0 {
+		t.Errorf("identical lines should not have any difference, not %v", diff)
+	}
+
+	diff = DiffLines([]string{"{", `"id": 1,`, `"name": "test"`, "}"}, []string{"{", `"id": 2,`, `"name": "test"`, "}"})
+	expected := `- "id": 1,|+ "id": 2,`
+	if strings.Join(diff, "|") != expected {
+		t.Errorf("the diff should be %s, not %s", expected, strings.Join(diff, "|"))
+	}
+
+	diff = DiffLines([]string{}, []string{"new"})
+	if strings.Join(diff, "|") != "+ new" {
+		t.Errorf("the diff should be + new, not %s", strings.Join(diff, "|"))
+	}
+}
+
+func TestDiffLinesIsMinimal(t *testing.T) {
+	before := strings.Split("a b c a b b a", " ")
+	after := strings.Split("c b a b a c", " ")
+	diff := DiffLines(before, after)
+	// the longest common subsequence has 4 lines, ex: b a b a
+	if len(diff) != len(before)+len(after)-2*4 {
+		t.Errorf("the diff should only have %d lines, not %v", len(before)+len(after)-8, diff)
+	}
+
+	removed := 0
+	for _, line := range diff {
+		if strings.HasPrefix(line, "- ") {
+			removed++
+		}
+	}
+	if removed != len(before)-4 {
+		t.Errorf("%d lines should be removed: %v", len(before)-4, diff)
+	}
+
+	// the first and last lines differ, so the whole responses go through the subsequence search
+	before = make([]string, 5000)
+	after = make([]string, 5000)
+	changes := 0
+	for i := range before {
+		before[i] = fmt.Sprintf("line %d", i)
+		after[i] = before[i]
+		if i%100 == 0 || i == len(before)-1 {
+			after[i] = fmt.Sprintf("changed %d", i)
+			changes++
+		}
+	}
+	diff = DiffLines(before, after)
+	if len(diff) != 2*changes {
+		t.Errorf("only the %d changed lines should be returned, not %d lines", changes, len(diff))
+	}
+	for index := 0; index+1 < len(diff); index += 2 {
+		if !strings.HasPrefix(diff[index], "- line ") || diff[index+1] != "+ changed "+strings.TrimPrefix(diff[index], "- line ") {
+			t.Errorf("each changed line should be replaced, not %s then %s", diff[index], diff[index+1])
+			break
+		}
+	}
+}
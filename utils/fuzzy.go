@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+)
+
+// return a score telling how well the pattern fuzzy matches the text, and
+// whether it matches at all. All the characters of the pattern must appear in
+// the text in the same order, but not necessarily next to each other.
+// Consecutive characters and characters at the beginning of a word (after a
+// slash, a dash, a dot etc) are worth more. The comparison ignores the case.
+func FuzzyScore(pattern string, text string) (int, bool) {
+	pattern = strings.ToLower(pattern)
+	if pattern == "" {
+		return 0, true
+	}
+	patternRunes := []rune(pattern)
+	textRunes := []rune(strings.ToLower(text))
+
+	score := 0
+	patternIndex := 0
+	previousMatch := -2
+	for textIndex, r := range textRunes {
+		if patternIndex == len(patternRunes) {
+			break
+		}
+		if r != patternRunes[patternIndex] {
+			continue
+		}
+		score += 1
+		if previousMatch == textIndex-1 {
+			score += 5
+		}
+		if textIndex == 0 || isWordSeparator(textRunes[textIndex-1]) {
+			score += 3
+		}
+		previousMatch = textIndex
+		patternIndex += 1
+	}
+	if patternIndex < len(patternRunes) {
+		return 0, false
+	}
+	// shorter texts are a better match for the same pattern
+	score -= len(textRunes) / 10
+	if score < 1 {
+		score = 1
+	}
+	return score, true
+}
+
+func isWordSeparator(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
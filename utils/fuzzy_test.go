@@ -0,0 +1,25 @@
+package utils
+
+import "testing"
+
+func TestFuzzyScore(t *testing.T) {
+	_, ok := FuzzyScore("dtpm", "demo/test/post_message")
+	if !ok {
+		t.Errorf("dtpm should match demo/test/post_message")
+	}
+	_, ok = FuzzyScore("mtd", "demo/test/post_message")
+	if ok {
+		t.Errorf("mtd should not match demo/test/post_message, the order matters")
+	}
+
+	consecutive, _ := FuzzyScore("post", "demo/test/post_message")
+	scattered, _ := FuzzyScore("post", "demo/p/o/s/t")
+	if consecutive <= scattered {
+		t.Errorf("consecutive characters should score more (%d) than scattered ones (%d)", consecutive, scattered)
+	}
+
+	upper, ok := FuzzyScore("POST", "demo/test/post_message")
+	if !ok || upper != consecutive {
+		t.Errorf("the comparison should ignore the case")
+	}
+}
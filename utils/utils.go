@@ -5,15 +5,21 @@ import (
 	"fmt"
 	"io/fs"
 	"os"
-	"path"
+	"path/filepath"
 )
 
+// the name of the directory holding the data of a workspace
+const WorkspaceDirName = ".gourl"
+
 var DataDirPath string
 
-func dirExists(path string) (bool, error) {
-	_, err := os.Stat(path)
+// how the data directory was chosen: --workspace, GOURL_HOME, project or home
+var DataDirSource string
+
+func DirExists(path string) (bool, error) {
+	info, err := os.Stat(path)
 	if err == nil {
-		return true, nil
+		return info.IsDir(), nil
 	}
 	if errors.Is(err, fs.ErrNotExist) {
 		return false, nil
@@ -21,15 +27,65 @@ func dirExists(path string) (bool, error) {
 	return false, err
 }
 
-func CreateDataDir() error {
-	// creating the data directory
+// return the data directory to use, and how it was chosen. In order of priority:
+// the workspace flag, the GOURL_HOME environment variable, the closest .gourl
+// directory from the current directory (like git does), or ~/.gourl
+func FindDataDir(workspace string) (string, string, error) {
+	if workspace != "" {
+		return workspace, "--workspace", nil
+	}
+	if gourlHome := os.Getenv("GOURL_HOME"); gourlHome != "" {
+		return gourlHome, "GOURL_HOME", nil
+	}
+
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
-		return fmt.Errorf("error while fetching home dir path: %v", err)
+		return "", "", fmt.Errorf("error while fetching home dir path: %v", err)
+	}
+	homeDataDir := filepath.Join(homeDir, WorkspaceDirName)
+
+	currentDir, err := os.Getwd()
+	if err != nil {
+		return "", "", fmt.Errorf("error while fetching the current directory: %v", err)
+	}
+	for dir := currentDir; ; dir = filepath.Dir(dir) {
+		candidate := filepath.Join(dir, WorkspaceDirName)
+		if candidate == homeDataDir {
+			break
+		}
+		exists, err := DirExists(candidate)
+		if err != nil {
+			return "", "", fmt.Errorf("error while looking for a workspace in %s: %v", dir, err)
+		}
+		if exists {
+			return candidate, "project", nil
+		}
+		if dir == filepath.Dir(dir) {
+			break
+		}
+	}
+	return homeDataDir, "home", nil
+}
+
+// find the data directory of the workspace and create it if needed
+func CreateDataDir(workspace string) error {
+	dataDirPath, source, err := FindDataDir(workspace)
+	if err != nil {
+		return err
+	}
+	return UseDataDir(dataDirPath, source)
+}
+
+// use this directory to store the data, it is created if it does not exist
+func UseDataDir(dataDirPath string, source string) error {
+	absolutePath, err := filepath.Abs(dataDirPath)
+	if err != nil {
+		return fmt.Errorf("error while resolving the data directory %s: %v", dataDirPath, err)
 	}
+	DataDirPath = absolutePath
+	DataDirSource = source
 
-	DataDirPath = path.Join(homeDir, ".gourl")
-	dataDirExist, err := dirExists(DataDirPath)
+	dataDirExist, err := DirExists(DataDirPath)
 	if err != nil {
 		return fmt.Errorf("error while checking if data dir exist: %v", err)
 	}
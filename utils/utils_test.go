@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFindDataDir(t *testing.T) {
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Errorf("%v\n", err)
+		return
+	}
+	home := filepath.Join(root, "home")
+	// a workspace above the home directory is not used from inside it
+	for _, dir := range []string{
+		filepath.Join(root, WorkspaceDirName),
+		filepath.Join(root, "outside"),
+		filepath.Join(home, WorkspaceDirName),
+		filepath.Join(home, "other"),
+		filepath.Join(home, "project", WorkspaceDirName),
+		filepath.Join(home, "project", "src", "api"),
+	} {
+		err := os.MkdirAll(dir, os.ModePerm)
+		if err != nil {
+			t.Errorf("%v\n", err)
+			return
+		}
+	}
+	t.Setenv("HOME", home)
+
+	tests := []struct {
+		workspace      string
+		gourlHome      string
+		dir            string
+		expectedDir    string
+		expectedSource string
+	}{
+		{"./ws", "/tmp/gourl", "project/src/api", "./ws", "--workspace"},
+		{"", "/tmp/gourl", "project/src/api", "/tmp/gourl", "GOURL_HOME"},
+		{"", "", "project/src/api", filepath.Join(home, "project", WorkspaceDirName), "project"},
+		{"", "", "project", filepath.Join(home, "project", WorkspaceDirName), "project"},
+		{"", "", "other", filepath.Join(home, WorkspaceDirName), "home"},
+		{"", "", "../outside", filepath.Join(root, WorkspaceDirName), "project"},
+	}
+	for _, test := range tests {
+		t.Setenv("GOURL_HOME", test.gourlHome)
+		t.Chdir(filepath.Join(home, test.dir))
+		dir, source, err := FindDataDir(test.workspace)
+		if err != nil {
+			t.Errorf("%v\n", err)
+			continue
+		}
+		if dir != test.expectedDir || source != test.expectedSource {
+			t.Errorf("from %s, the data directory should be %s (%s), not %s (%s)", test.dir, test.expectedDir, test.expectedSource, dir, source)
+		}
+	}
+}